@@ -0,0 +1,337 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+
+// Package mud provides an audit trail of the changes made to models.
+package mud
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/markoxley/mud/order"
+	"github.com/markoxley/mud/where"
+)
+
+// Kinds of change recorded in the audit trail
+const (
+	AuditInsert  = "insert"
+	AuditUpdate  = "update"
+	AuditDisable = "disable"
+	AuditDelete  = "delete"
+)
+
+// AuditEntry records a change to a model when Config.Audit is set.
+// Entries are written in the same transaction as the change.
+type AuditEntry struct {
+	Model
+	// Entity is the table of the changed model
+	Entity string `mud:"size:128,key"`
+	// EntityID is the ID of the changed model
+	EntityID string `mud:"size:64,key"`
+	// Kind is the kind of change: AuditInsert, AuditUpdate, AuditDisable or AuditDelete
+	Kind string `mud:"size:16"`
+	// Actor is the user that made the change, taken from the context of the handle
+	Actor string `mud:"size:256"`
+	// Changes holds the changed columns as JSON. Use Diff to read them
+	Changes string `mud:"size:65535"`
+}
+
+// KeyGenerator orders the entries by the time they were written.
+func (AuditEntry) KeyGenerator() IDGenerator {
+	return SequentialGUID
+}
+
+// AuditChange holds the values of a column before and after a change.
+// Values are nil when the column was null, or the row did not exist.
+type AuditChange struct {
+	Old *string `json:"old"`
+	New *string `json:"new"`
+}
+
+// Diff returns the changed columns of the entry
+// @return map[string]AuditChange
+// @return error
+func (e AuditEntry) Diff() (map[string]AuditChange, error) {
+	res := make(map[string]AuditChange)
+	if e.Changes == "" {
+		return res, nil
+	}
+	if err := json.Unmarshal([]byte(e.Changes), &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// actorKey is the context key of the acting user
+type actorKey struct{}
+
+// WithActor returns a copy of ctx carrying the user recorded in the audit
+// trail for changes made through DB.WithContext.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the acting user carried by ctx
+func ActorFromContext(ctx context.Context) (string, bool) {
+	a, ok := ctx.Value(actorKey{}).(string)
+	return a, ok
+}
+
+// History returns the audit trail of the model, oldest first
+// @param m
+// @return []*AuditEntry
+// @return error
+func (db *DB) History(m Modeller) ([]*AuditEntry, error) {
+	if m.GetID() == nil {
+		return []*AuditEntry{}, nil
+	}
+	flds, n, err := db.tableTest(m)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.tenantModel(m, flds); err != nil {
+		return nil, err
+	}
+	return Fetch[AuditEntry](db, &Criteria{
+		Where: where.Equal("Entity", n).AndEqual("EntityID", *m.GetID()),
+		Order: order.Asc("ID"),
+	})
+}
+
+// audited returns true if changes to the model are recorded
+// @param m
+// @return bool
+func (db *DB) audited(m Modeller) bool {
+	return db.cfg.Audit && GetTableName(m) != "AuditEntry"
+}
+
+// prepareAudit creates the tables of the model and the audit trail, so that
+// they are not created while the transaction of a change holds its locks
+// @param m
+// @return error
+func (db *DB) prepareAudit(m Modeller) error {
+	if _, _, err := db.tableTest(m); err != nil {
+		return err
+	}
+	_, _, err := db.tableTest(&AuditEntry{})
+	return err
+}
+
+// withTransaction calls fn with the transaction given, or with a new transaction
+// on the primary that is committed if fn succeeds and rolled back otherwise
+// @param tx
+// @param fn
+// @return error
+func (db *DB) withTransaction(tx []*sql.Tx, fn func(tx ...*sql.Tx) error) error {
+	if len(tx) > 0 || db.cfg.DisabledTransactions {
+		return fn(tx...)
+	}
+	qtx, err := db.beginTransaction(db.db)
+	if err != nil {
+		return err
+	}
+	if err := fn(qtx); err != nil {
+		db.RollbackTransaction(qtx)
+		return err
+	}
+	return db.CommitTransaction(qtx)
+}
+
+// saveAudited saves the model and records the change
+// @param m
+// @param tx
+// @return error
+func (db *DB) saveAudited(m Modeller, tx ...*sql.Tx) error {
+	if err := db.prepareAudit(m); err != nil {
+		return err
+	}
+	return db.withTransaction(tx, func(tx ...*sql.Tx) error {
+		kind := AuditInsert
+		var before []map[string]interface{}
+		if !m.IsNew() {
+			kind = AuditUpdate
+			var err error
+			if before, err = db.auditRows(m, []string{*m.GetID()}, tx...); err != nil {
+				return err
+			}
+		}
+		if err := db.save(m, tx...); err != nil {
+			return err
+		}
+		after, err := db.auditRows(m, []string{*m.GetID()}, tx...)
+		if err != nil {
+			return err
+		}
+		return db.writeAudit(m, kind, before, after, tx...)
+	})
+}
+
+// removeAudited removes the models matching the criteria and records the
+// changes. Returns the number of rows affected
+// @param m
+// @param c
+// @param tx
+// @return int64
+// @return error
+func (db *DB) removeAudited(m Modeller, c *Criteria, tx ...*sql.Tx) (int64, error) {
+	rc, err := db.removeCriteria(m, c)
+	if err != nil {
+		return 0, err
+	}
+	kind := AuditDisable
+	if db.cfg.Deletable {
+		kind = AuditDelete
+	}
+	op := Operation{Name: OpDelete, Table: GetTableName(m), Statement: db.removeStatement(m, rc)}
+	return db.execAudited(m, rc, kind, op, tx...)
+}
+
+// execAudited executes a statement that changes the models matching the
+// criteria, and records the changes. Returns the number of rows affected
+// @param m
+// @param c
+// @param kind
+// @param op
+// @param tx
+// @return int64
+// @return error
+func (db *DB) execAudited(m Modeller, c *Criteria, kind string, op Operation, tx ...*sql.Tx) (int64, error) {
+	if err := db.prepareAudit(m); err != nil {
+		return 0, err
+	}
+	var rows int64
+	err := db.withTransaction(tx, func(tx ...*sql.Tx) error {
+		n := GetTableName(m)
+		q := fmt.Sprintf("SELECT * FROM %s %s", db.mgr.IdentityString(n), strings.TrimSpace(c.WhereString(db.mgr)))
+		before, err := db.rawSelect(Operation{Name: OpSelect, Table: n, Statement: q}, tx...)
+		if err != nil {
+			return err
+		}
+		res, err := db.execute(op, tx...)
+		if err != nil {
+			return err
+		}
+		if rows, err = res.RowsAffected(); err != nil {
+			return err
+		}
+		if len(before) == 0 {
+			return nil
+		}
+		if kind == AuditDelete {
+			return db.writeAudit(m, kind, before, nil, tx...)
+		}
+		ids := make([]string, 0, len(before))
+		for _, r := range before {
+			if id, ok := r["ID"].(*string); ok && id != nil {
+				ids = append(ids, *id)
+			}
+		}
+		after, err := db.auditRows(m, ids, tx...)
+		if err != nil {
+			return err
+		}
+		return db.writeAudit(m, kind, before, after, tx...)
+	})
+	return rows, err
+}
+
+// auditRows reads the stored rows of the models with the IDs given
+// @param m
+// @param ids
+// @param tx
+// @return []map[string]interface{}
+// @return error
+func (db *DB) auditRows(m Modeller, ids []string, tx ...*sql.Tx) ([]map[string]interface{}, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	vls := make([]string, 0, len(ids))
+	for _, id := range ids {
+		v, _ := db.mgr.MakeValue(id)
+		vls = append(vls, v)
+	}
+	n := GetTableName(m)
+	q := fmt.Sprintf("SELECT * FROM %s WHERE %s IN (%s)", db.mgr.IdentityString(n), db.mgr.IdentityString("ID"), strings.Join(vls, ", "))
+	return db.rawSelect(Operation{Name: OpSelect, Table: n, Statement: q}, tx...)
+}
+
+// writeAudit writes an audit entry for each changed row.
+// Rows are matched by ID, and rows without changes are not recorded
+// @param m
+// @param kind
+// @param before
+// @param after
+// @param tx
+// @return error
+func (db *DB) writeAudit(m Modeller, kind string, before, after []map[string]interface{}, tx ...*sql.Tx) error {
+	rows := make(map[string][2]map[string]interface{})
+	ids := make([]string, 0, len(before)+len(after))
+	for i, set := range [][]map[string]interface{}{before, after} {
+		for _, r := range set {
+			id, ok := r["ID"].(*string)
+			if !ok || id == nil {
+				continue
+			}
+			if _, ok := rows[*id]; !ok {
+				ids = append(ids, *id)
+			}
+			pair := rows[*id]
+			pair[i] = r
+			rows[*id] = pair
+		}
+	}
+	actor, _ := ActorFromContext(db.context())
+	n := GetTableName(m)
+	for _, id := range ids {
+		changes := auditDiff(rows[id][0], rows[id][1])
+		if len(changes) == 0 {
+			continue
+		}
+		b, err := json.Marshal(changes)
+		if err != nil {
+			return err
+		}
+		e := &AuditEntry{Entity: n, EntityID: id, Kind: kind, Actor: actor, Changes: string(b)}
+		if err := db.save(e, tx...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditDiff returns the columns whose values differ between two versions of a row.
+// The ID and time of the last update are not recorded
+// @param before
+// @param after
+// @return map[string]AuditChange
+func auditDiff(before, after map[string]interface{}) map[string]AuditChange {
+	cols := make([]string, 0, len(before)+len(after))
+	for c := range before {
+		cols = append(cols, c)
+	}
+	for c := range after {
+		if _, ok := before[c]; !ok {
+			cols = append(cols, c)
+		}
+	}
+	value := func(r map[string]interface{}, c string) *string {
+		v, _ := r[c].(*string)
+		return v
+	}
+	res := make(map[string]AuditChange)
+	for _, c := range cols {
+		if c == "ID" || c == "LastUpdate" {
+			continue
+		}
+		o, n := value(before, c), value(after, c)
+		if (o == nil && n == nil) || (o != nil && n != nil && *o == *n) {
+			continue
+		}
+		res[c] = AuditChange{Old: o, New: n}
+	}
+	return res
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+
+// Package mud provides batch processing of large result sets.
+package mud
+
+import (
+	"errors"
+
+	"github.com/markoxley/mud/order"
+	"github.com/markoxley/mud/utils"
+)
+
+// ErrNoCursorSecret is returned when checkpoints are used without
+// Config.CursorSecret, as they could not be resumed by another process.
+var ErrNoCursorSecret = errors.New("checkpoints require a cursor secret")
+
+// BatchOptions configures the processing of models in batches.
+type BatchOptions struct {
+	// Order is the order the models are processed in. ID is added as a final
+	// tiebreaker, and models are processed in ID order when Order is nil
+	Order *order.Builder
+	// Resume is a checkpoint from a previous run. Processing continues with the
+	// batch after the one the checkpoint was taken for. Checkpoints are signed
+	// with Config.CursorSecret, which must be set to use them
+	Resume string
+	// Checkpoint is called after each batch has been processed, with a checkpoint
+	// that can be passed as Resume to continue after the batch. Returning an
+	// error stops processing
+	Checkpoint func(checkpoint string) error
+}
+
+// InBatches calls fn with successive batches of up to size models that match
+// the criteria, until all models have been processed or fn returns an error.
+// Each batch is read with its own query, using keyset pagination, so no
+// transaction or result set is held open between batches and fn is free to
+// modify the models. The error returned by fn or Checkpoint is returned.
+// ErrNoCursorSecret is returned if checkpoints are used without a cursor secret.
+// @param mdl
+// @param size
+// @param criteria
+// @param fn
+// @param opts
+// @return error
+func (db *DB) InBatches(mdl Modeller, size int, criteria interface{}, fn func(batch []Modeller) error, opts ...BatchOptions) error {
+	c, err := db.getCriteria([]interface{}{criteria})
+	if err != nil {
+		return err
+	}
+	var opt BatchOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	// A random key would not verify the checkpoints of an earlier process
+	if (opt.Resume != "" || opt.Checkpoint != nil) && db.cfg.CursorSecret == "" {
+		return ErrNoCursorSecret
+	}
+	cur := opt.Resume
+	for {
+		ms, next, _, err := db.pageCursor(mdl, cur, size, opt.Order, c)
+		if err != nil {
+			return err
+		}
+		if len(ms) == 0 {
+			return nil
+		}
+		if err := fn(ms); err != nil {
+			return err
+		}
+		// The last model of the batch is the checkpoint, even on the final batch,
+		// so that rows added later are picked up on resumption
+		if opt.Checkpoint != nil {
+			cp := next
+			if cp == "" {
+				flds, n, err := db.tableTest(mdl)
+				if err != nil {
+					return err
+				}
+				keys, err := sortFields(flds, opt.Order)
+				if err != nil {
+					return err
+				}
+				if cp, err = db.encodeCursor(cursorID(n, keys), false, keys, flds, ms[len(ms)-1]); err != nil {
+					return err
+				}
+			}
+			if err := opt.Checkpoint(cp); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		cur = next
+	}
+}
+
+// InBatches calls fn with successive batches of up to size models that match
+// the criteria, until all models have been processed or fn returns an error.
+// See DB.InBatches for details
+func InBatches[T Modeller](db *DB, size int, criteria interface{}, fn func(batch []*T) error, opts ...BatchOptions) error {
+	return db.InBatches(*new(T), size, criteria, func(batch []Modeller) error {
+		items := make([]*T, 0, len(batch))
+		for _, m := range batch {
+			items = append(items, utils.Ptr(m.(T)))
+		}
+		return fn(items)
+	}, opts...)
+}
@@ -0,0 +1,332 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+
+// Package mud provides query result caching for database models.
+package mud
+
+import (
+	"container/list"
+	"database/sql"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheSize is the number of query results held by the default cache
+const DefaultCacheSize = 1000
+
+// CacheEntry holds the result of a cached query.
+// Values are held as the strings read from the database, so that each cache
+// hit populates new models and entries can be serialized by external backends.
+type CacheEntry struct {
+	// Columns holds the column names of the result
+	Columns []string
+	// Rows holds the values of each row, with nil for NULL values
+	Rows [][]*string
+}
+
+// Cache defines the interface for query result cache backends.
+// Entries are keyed by the table name and the generated SQL, and are
+// invalidated by table whenever the table is written to.
+type Cache interface {
+	// Get returns the entry stored under key, if it exists and has not expired
+	Get(key string) (*CacheEntry, bool)
+	// Set stores an entry for a query on table. A ttl of zero keeps the entry
+	// until it is invalidated or evicted
+	Set(table string, key string, e *CacheEntry, ttl time.Duration)
+	// Invalidate removes all entries for queries on table
+	Invalidate(table string)
+}
+
+// CacheTTLer may be implemented by a model to set how long its query
+// results are cached, when the query does not specify a TTL.
+type CacheTTLer interface {
+	// CacheTTL returns the time query results of the model are cached for
+	CacheTTL() time.Duration
+}
+
+// SetCache sets the cache used for queries whose criteria enable caching.
+// A DB uses an in-memory LRU cache by default. Passing nil disables caching.
+// @param c
+func (db *DB) SetCache(c Cache) {
+	db.cache = c
+}
+
+// cacheKey returns the cache key of a query on a table
+func cacheKey(table, query string) string {
+	return table + "\x00" + query
+}
+
+// cacheTTL returns the time the results of a query are cached for.
+// The TTL of the criteria takes precedence over that of the model
+func cacheTTL(m Modeller, c *Criteria) time.Duration {
+	if c.CacheTTL > 0 {
+		return c.CacheTTL
+	}
+	if t, ok := m.(CacheTTLer); ok {
+		return t.CacheTTL()
+	}
+	t := reflect.TypeOf(m)
+	if t.Kind() != reflect.Pointer {
+		if t, ok := reflect.New(t).Interface().(CacheTTLer); ok {
+			return t.CacheTTL()
+		}
+	}
+	return 0
+}
+
+// invalidateCache removes the cached results of the tables written by an operation.
+func (db *DB) invalidateCache(op Operation) {
+	tables := db.writtenTables(op)
+	db.invalidated(tables)
+	for _, t := range tables {
+		db.cache.Invalidate(t)
+	}
+}
+
+// writtenTables returns the tables written by an operation. Raw statements
+// other than queries write every known table they mention
+func (db *DB) writtenTables(op Operation) []string {
+	switch op.Name {
+	case OpInsert, OpUpdate, OpDelete, OpCreateTable:
+		return []string{op.Table}
+	case OpRaw:
+		stmt := strings.TrimSpace(op.Statement)
+		if len(stmt) >= 6 && strings.EqualFold(stmt[:6], "select") {
+			return nil
+		}
+		res := make([]string, 0)
+		for t := range db.tableDef {
+			if mentionsTable(stmt, t) {
+				res = append(res, t)
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+// txWrites records the tables written by open transactions. Their results are
+// not cached until the transactions end, as queries outside of a transaction
+// do not see its writes, and are invalidated again when they do. It also
+// counts the invalidations of each table, so that a query that ran before an
+// invalidation does not cache its results after it
+type txWrites struct {
+	mu          sync.Mutex
+	tables      map[*sql.Tx][]string
+	open        map[string]int
+	generations map[string]uint64
+}
+
+// newTxWrites creates an empty record of transaction writes
+func newTxWrites() *txWrites {
+	return &txWrites{tables: make(map[*sql.Tx][]string), open: make(map[string]int), generations: make(map[string]uint64)}
+}
+
+// written records that the transaction has written the tables of the operation
+// @param tx
+// @param op
+func (db *DB) written(tx *sql.Tx, op Operation) {
+	if db.writes == nil || tx == nil {
+		return
+	}
+	w := db.writes
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, t := range db.writtenTables(op) {
+		if slices.Contains(w.tables[tx], t) {
+			continue
+		}
+		w.tables[tx] = append(w.tables[tx], t)
+		w.open[t]++
+	}
+}
+
+// endWrites forgets the tables written by a transaction that has ended, and
+// removes the results cached while it was open
+// @param tx
+func (db *DB) endWrites(tx *sql.Tx) {
+	if db.writes == nil {
+		return
+	}
+	w := db.writes
+	w.mu.Lock()
+	tables := w.tables[tx]
+	delete(w.tables, tx)
+	for _, t := range tables {
+		if w.open[t]--; w.open[t] <= 0 {
+			delete(w.open, t)
+		}
+		w.generations[t]++
+	}
+	w.mu.Unlock()
+	if db.cache == nil {
+		return
+	}
+	for _, t := range tables {
+		db.cache.Invalidate(t)
+	}
+}
+
+// invalidated records that the cached results of the tables are being removed
+// @param tables
+func (db *DB) invalidated(tables []string) {
+	if db.writes == nil || len(tables) == 0 {
+		return
+	}
+	db.writes.mu.Lock()
+	defer db.writes.mu.Unlock()
+	for _, t := range tables {
+		db.writes.generations[t]++
+	}
+}
+
+// generation returns the number of times the cached results of the table
+// have been invalidated, which is taken before a query is run
+// @param table
+// @return uint64
+func (db *DB) generation(table string) uint64 {
+	if db.writes == nil {
+		return 0
+	}
+	db.writes.mu.Lock()
+	defer db.writes.mu.Unlock()
+	return db.writes.generations[table]
+}
+
+// storeCache caches the results of a query of the table, unless an open
+// transaction has written the table, as the results may be stale once it
+// commits, or the table has been invalidated since the query began
+// @param table
+// @param key
+// @param e
+// @param ttl
+// @param gen
+func (db *DB) storeCache(table string, key string, e *CacheEntry, ttl time.Duration, gen uint64) {
+	if db.writes == nil {
+		db.cache.Set(table, key, e, ttl)
+		return
+	}
+	// The lock is held while storing, so that an invalidation either sees the
+	// results and removes them, or is seen here
+	db.writes.mu.Lock()
+	defer db.writes.mu.Unlock()
+	if db.writes.open[table] > 0 || db.writes.generations[table] != gen {
+		return
+	}
+	db.cache.Set(table, key, e, ttl)
+}
+
+// mentionsTable returns true if the statement refers to the table by name
+func mentionsTable(stmt, table string) bool {
+	re, err := regexp.Compile(`(?i)(^|[^\w])` + regexp.QuoteMeta(table) + `($|[^\w])`)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(stmt)
+}
+
+// lruItem is an entry held by an LRUCache
+type lruItem struct {
+	key     string
+	table   string
+	entry   *CacheEntry
+	expires time.Time
+}
+
+// LRUCache is an in-memory Cache that evicts the least recently used entries
+// once it holds its maximum number of entries. It is safe for concurrent use.
+type LRUCache struct {
+	mu     sync.Mutex
+	size   int
+	order  *list.List
+	items  map[string]*list.Element
+	tables map[string]map[string]struct{}
+}
+
+// NewLRUCache creates an LRUCache holding at most size entries.
+func NewLRUCache(size int) *LRUCache {
+	if size < 1 {
+		size = DefaultCacheSize
+	}
+	return &LRUCache{
+		size:   size,
+		order:  list.New(),
+		items:  make(map[string]*list.Element),
+		tables: make(map[string]map[string]struct{}),
+	}
+}
+
+// Get returns the entry stored under key, if it exists and has not expired.
+func (c *LRUCache) Get(key string) (*CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	it := el.Value.(*lruItem)
+	if !it.expires.IsZero() && time.Now().After(it.expires) {
+		c.remove(el)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return it.entry, true
+}
+
+// Set stores an entry for a query on table, evicting the least recently used
+// entry if the cache is full.
+func (c *LRUCache) Set(table string, key string, e *CacheEntry, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	it := &lruItem{key: key, table: table, entry: e}
+	if ttl > 0 {
+		it.expires = time.Now().Add(ttl)
+	}
+	c.items[key] = c.order.PushFront(it)
+	if c.tables[table] == nil {
+		c.tables[table] = make(map[string]struct{})
+	}
+	c.tables[table][key] = struct{}{}
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// Invalidate removes all entries for queries on table.
+func (c *LRUCache) Invalidate(table string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.tables[table] {
+		if el, ok := c.items[key]; ok {
+			c.remove(el)
+		}
+	}
+	delete(c.tables, table)
+}
+
+// Len returns the number of entries in the cache.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// remove deletes an element from the cache. The caller must hold the lock
+func (c *LRUCache) remove(el *list.Element) {
+	it := c.order.Remove(el).(*lruItem)
+	delete(c.items, it.key)
+	if keys, ok := c.tables[it.table]; ok {
+		delete(keys, it.key)
+		if len(keys) == 0 {
+			delete(c.tables, it.table)
+		}
+	}
+}
@@ -6,6 +6,31 @@
 // with support for SQLite, MySQL, and SQL Server databases.
 package mud
 
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// TLSMode specifies how a connection to the database server is encrypted.
+type TLSMode string
+
+// TLS modes supported by the network database managers.
+// SQLite databases are local files and ignore the TLS mode.
+const (
+	// TLSDefault leaves encryption to the driver default
+	TLSDefault TLSMode = ""
+	// TLSDisable never encrypts the connection
+	TLSDisable TLSMode = "disable"
+	// TLSPrefer encrypts the connection if the server supports it
+	TLSPrefer TLSMode = "prefer"
+	// TLSRequire always encrypts the connection, without verifying the server certificate
+	TLSRequire TLSMode = "require"
+	// TLSVerify always encrypts the connection and verifies the server certificate
+	TLSVerify TLSMode = "verify"
+)
+
 // Config represents the configuration for a database connection.
 // It contains all the necessary information to establish and configure a database connection.
 type Config struct {
@@ -28,4 +53,128 @@ type Config struct {
 	// DisabledTransactions indicates whether database transactions should be disabled
 	// When true, each operation will be executed independently
 	DisabledTransactions bool `json:"disabledTransactions,omitzero"`
+	// Audit indicates whether changes to models are recorded in the AuditEntry table
+	Audit bool `json:"audit,omitzero"`
+
+	// Port specifies the port of the database server
+	// When zero, the port in Host or the driver default is used
+	Port int `json:"port,omitzero"`
+	// TLS specifies how the connection to the database server is encrypted
+	TLS TLSMode `json:"tls,omitzero"`
+	// ConnectTimeout limits the time taken to establish a connection
+	ConnectTimeout time.Duration `json:"connectTimeout,omitzero"`
+	// ReadTimeout limits the time spent waiting for the server to respond
+	// It is not supported by SQL Server, which uses context deadlines instead
+	ReadTimeout time.Duration `json:"readTimeout,omitzero"`
+	// Options holds driver specific settings that are added to the connection string
+	Options map[string]string `json:"options,omitzero"`
+
+	// MaxOpenConns limits the number of open connections in the pool
+	// When zero, the number of connections is unlimited
+	MaxOpenConns int `json:"maxOpenConns,omitzero"`
+	// MaxIdleConns limits the number of idle connections kept in the pool
+	// When zero, the driver default is used. A negative value keeps no idle connections
+	MaxIdleConns int `json:"maxIdleConns,omitzero"`
+	// ConnMaxLifetime is the maximum time a connection may be reused
+	ConnMaxLifetime time.Duration `json:"connMaxLifetime,omitzero"`
+	// ConnMaxIdleTime is the maximum time a connection may sit idle before it is closed
+	ConnMaxIdleTime time.Duration `json:"connMaxIdleTime,omitzero"`
+
+	// CursorSecret is the key used to sign pagination cursors
+	// When empty, a random key is used, cursors are only valid within the process,
+	// and InBatches checkpoints cannot be used
+	CursorSecret string `json:"cursorSecret,omitzero"`
+
+	// Replicas holds the connection settings of read replicas of the database
+	// Settings left empty are taken from the primary, so a replica usually only needs a Host
+	Replicas []Config `json:"replicas,omitzero"`
+	// HealthCheckInterval is the time between health checks of the replicas
+	// When zero, DefaultHealthCheckInterval is used
+	HealthCheckInterval time.Duration `json:"healthCheckInterval,omitzero"`
+
+	// Tenancy specifies how the data of tenants is separated
+	Tenancy TenancyMode `json:"tenancy,omitzero"`
+	// TenantDatabase is the name of the database of each tenant when Tenancy is
+	// TenancyDatabase, in which {tenant} is replaced by the tenant ID
+	TenantDatabase string `json:"tenantDatabase,omitzero"`
+}
+
+// replica returns the complete settings of a read replica, taking any
+// setting the replica leaves empty from c.
+func (c *Config) replica(r Config) *Config {
+	res := *c
+	res.Replicas = nil
+	strs := []struct {
+		src string
+		dst *string
+	}{
+		{r.Type, &res.Type},
+		{r.Host, &res.Host},
+		{r.Database, &res.Database},
+		{r.User, &res.User},
+		{r.Password, &res.Password},
+	}
+	for _, s := range strs {
+		if s.src != "" {
+			*s.dst = s.src
+		}
+	}
+	if r.Port != 0 {
+		res.Port = r.Port
+	}
+	if r.TLS != TLSDefault {
+		res.TLS = r.TLS
+	}
+	if r.Options != nil {
+		res.Options = r.Options
+	}
+	ints := []struct {
+		src int
+		dst *int
+	}{
+		{r.MaxOpenConns, &res.MaxOpenConns},
+		{r.MaxIdleConns, &res.MaxIdleConns},
+	}
+	for _, i := range ints {
+		if i.src != 0 {
+			*i.dst = i.src
+		}
+	}
+	durs := []struct {
+		src time.Duration
+		dst *time.Duration
+	}{
+		{r.ConnectTimeout, &res.ConnectTimeout},
+		{r.ReadTimeout, &res.ReadTimeout},
+		{r.ConnMaxLifetime, &res.ConnMaxLifetime},
+		{r.ConnMaxIdleTime, &res.ConnMaxIdleTime},
+	}
+	for _, d := range durs {
+		if d.src != 0 {
+			*d.dst = d.src
+		}
+	}
+	return &res
+}
+
+// address returns the host of the database server, including the port if one is configured.
+// A port specified in Port takes precedence over one included in Host.
+func (c *Config) address() string {
+	if c.Port < 1 {
+		return c.Host
+	}
+	host := c.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return net.JoinHostPort(host, strconv.Itoa(c.Port))
+}
+
+// options returns the driver options as query string values.
+// Options specified in Config take precedence over those in params.
+func (c *Config) options(params url.Values) string {
+	for k, v := range c.Options {
+		params.Set(k, v)
+	}
+	return params.Encode()
 }
@@ -32,7 +32,7 @@ func Version() string {
 // fieldTrans maps database field types to their corresponding Go reflect kinds.
 // This is used for type conversion between database values and Go types.
 var (
-	fieldTrans = map[fieldType][]reflect.Kind{
+	fieldTrans = map[FieldType][]reflect.Kind{
 		// Boolean fields map to Go bool type
 		tBool: {reflect.Bool},
 		// DateTime fields map to Go struct (time.Time)
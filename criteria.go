@@ -6,11 +6,19 @@
 package mud
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/markoxley/mud/order"
 	"github.com/markoxley/mud/where"
 )
 
+// ErrInvalidCondition is returned when a condition is set but cannot be rendered,
+// such as an In with no values, where ignoring it would change every row.
+var ErrInvalidCondition = errors.New("invalid condition")
+
 // Criteria is used to safely build search criteria for database queries.
 // It provides a structured way to define WHERE, ORDER BY, LIMIT, and OFFSET conditions.
 type Criteria struct {
@@ -24,6 +32,38 @@ type Criteria struct {
 	Offset int
 	// IncDeleted indicates whether to include soft-deleted records
 	IncDeleted bool
+	// Cache indicates whether the results of the query may be served from,
+	// and stored in, the cache of the database
+	Cache bool
+	// CacheTTL specifies how long the results are cached for. When zero, the
+	// TTL of the model is used, and otherwise results are kept until invalidated
+	CacheTTL time.Duration
+
+	// extraWhere holds conditions added by mud, which are combined with Where
+	extraWhere []string
+	// unscoped indicates the default scope of the model is not applied
+	unscoped bool
+	// table is the table queried, which full-text conditions refer to
+	table string
+	// subqueries indicates the condition queries the tables of other models
+	subqueries bool
+}
+
+// Unscoped returns a copy of the criteria that bypasses the default scope of
+// the model. Soft-deleted models are still excluded unless IncDeleted is set,
+// and tenant filtering always applies
+// @return *Criteria
+func (c Criteria) Unscoped() *Criteria {
+	c.unscoped = true
+	return &c
+}
+
+// withWhere returns a copy of the criteria with an additional condition
+// @param cond
+// @return *Criteria
+func (c Criteria) withWhere(cond string) *Criteria {
+	c.extraWhere = append(append(make([]string, 0, len(c.extraWhere)+1), c.extraWhere...), cond)
+	return &c
 }
 
 // WhereString returns the WHERE condition in SQL format.
@@ -36,18 +76,26 @@ type Criteria struct {
 //
 //	A string containing the SQL WHERE clause
 func (c Criteria) WhereString(mgr Manager) string {
+	return c.whereSQL(mgr.Operators(), mgr.IdentityString, mgr.MakeValue)
+}
+
+// whereSQL returns the WHERE clause, rendering conditions with the operator
+// formats, quoting identifiers with quote and converting values with value
+// @param operators
+// @param quote
+// @param value
+// @return string
+func (c Criteria) whereSQL(operators []string, quote func(string) string, value func(interface{}) (string, bool)) string {
 	// if c.Where == nil {
 	// 	return ""
 	// }
-	wh := ""
+	wh := c.renderCondition(operators, value)
 	whereDone := false
-	if c.Where != nil {
-		if b, ok := c.Where.(*where.Builder); ok {
-			wh = b.String(mgr.Operators())
-		} else if b, ok := c.Where.(where.Builder); ok {
-			wh = b.String(mgr.Operators())
-		} else if w, ok := c.Where.(string); ok {
-			wh = w
+	for _, x := range c.extraWhere {
+		if wh == "" {
+			wh = x
+		} else {
+			wh = fmt.Sprintf("(%s) AND (%s)", wh, x)
 		}
 	}
 
@@ -61,11 +109,98 @@ func (c Criteria) WhereString(mgr Manager) string {
 		} else {
 			wh += "WHERE"
 		}
-		wh += fmt.Sprintf(" %s IS NULL", mgr.IdentityString("DeleteDate"))
+		wh += fmt.Sprintf(" %s IS NULL", quote("DeleteDate"))
 	}
 	return wh
 }
 
+// invalidWhere reports whether the criteria has a condition that renders to
+// nothing, which would leave the statement without it
+// @param mgr
+// @return bool
+func (c Criteria) invalidWhere(mgr Manager) bool {
+	if c.Where == nil || c.Where == "" {
+		return false
+	}
+	return c.renderCondition(mgr.Operators(), mgr.MakeValue) == ""
+}
+
+// renderCondition renders the Where condition of the criteria, with the
+// {table} placeholder of the operator formats replaced by its table. The
+// queries in the condition are given the formats as they were, so that
+// they refer to their own tables
+// @param operators
+// @param value
+// @return string
+func (c Criteria) renderCondition(operators []string, value func(interface{}) (string, bool)) string {
+	if c.table == "" {
+		return renderWhere(operators, value, c.Where)
+	}
+	w := c.Where
+	switch b := w.(type) {
+	case *where.Builder:
+		w = b.MapQueries(func(q where.Query) where.Query { return tableQuery{q: q, operators: operators} })
+	case where.Builder:
+		w = b.MapQueries(func(q where.Query) where.Query { return tableQuery{q: q, operators: operators} })
+	}
+	return renderWhere(tableOperators(operators, c.table), value, w)
+}
+
+// tableQuery is a query rendered with the operator formats of the query that
+// contains it, before their {table} placeholder was replaced
+type tableQuery struct {
+	q         where.Query
+	operators []string
+}
+
+// Select returns the SQL of the query, ignoring the operator formats it is given
+// @param operators
+// @param quote
+// @param value
+// @return string
+func (t tableQuery) Select(_ []string, quote func(string) string, value func(interface{}) (string, bool)) string {
+	return t.q.Select(t.operators, quote, value)
+}
+
+// tableOperators returns a copy of the operator formats with the {table}
+// placeholder replaced by the table name
+// @param operators
+// @param table
+// @return []string
+func tableOperators(operators []string, table string) []string {
+	res := make([]string, len(operators))
+	for i, o := range operators {
+		res[i] = strings.ReplaceAll(o, "{table}", table)
+	}
+	return res
+}
+
+// whereString renders a WHERE condition given as a where.Builder or a string
+// @param mgr
+// @param w
+// @return string
+func whereString(mgr Manager, w interface{}) string {
+	return renderWhere(mgr.Operators(), mgr.MakeValue, w)
+}
+
+// renderWhere renders a WHERE condition with the operator formats, converting
+// values to the literals of the dialect with value
+// @param operators
+// @param value
+// @param w
+// @return string
+func renderWhere(operators []string, value func(interface{}) (string, bool), w interface{}) string {
+	switch b := w.(type) {
+	case *where.Builder:
+		return b.Render(operators, value)
+	case where.Builder:
+		return b.Render(operators, value)
+	case string:
+		return b
+	}
+	return ""
+}
+
 // OrderString returns the ORDER BY condition in SQL format.
 // It converts the criteria's Order condition into a properly formatted SQL ORDER BY clause.
 // Parameters:
@@ -79,19 +214,22 @@ func (c Criteria) OrderString(mgr Manager) string {
 	if c.Order == nil {
 		return ""
 	}
-	order := ""
-	switch c.Order.(type) {
+	ord := ""
+	switch o := c.Order.(type) {
+	case *order.Builder:
+		ord = o.RenderWith(mgr.IdentityString, func(field, query string) string {
+			return mgr.RelevanceOrder(c.table, field, query)
+		})
 	case string:
-		order, _ = c.Order.(string)
+		ord = o
 	case fmt.Stringer:
-		st, _ := c.Order.(fmt.Stringer)
-		order = st.String()
+		ord = o.String()
 	}
 
-	if order != "" {
-		order = fmt.Sprintf(" ORDER BY %s", order)
+	if ord != "" {
+		ord = fmt.Sprintf(" ORDER BY %s", ord)
 	}
-	return order
+	return ord
 }
 
 // LimitString returns the limiter in SQL format
@@ -0,0 +1,334 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+
+// Package mud provides keyset (cursor) pagination of models.
+package mud
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/markoxley/mud/order"
+	"github.com/markoxley/mud/utils"
+)
+
+// ErrInvalidCursor is returned when a cursor is malformed, has been tampered
+// with, or was created for a different model or ordering.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorPage holds a page of models read with keyset pagination.
+type CursorPage[T Modeller] struct {
+	// Items holds the models on the page
+	Items []*T
+	// Next is the cursor of the following page, or empty if this is the last page
+	Next string
+	// Prev is the cursor of the preceding page, or empty if this is the first page
+	Prev string
+}
+
+// cursor is the signed content of a cursor string
+type cursor struct {
+	// Key identifies the model and ordering the cursor was created for
+	Key string `json:"k"`
+	// Back is true if the cursor reads the rows before the boundary row
+	Back bool `json:"b,omitempty"`
+	// Values holds the sort keys of the boundary row as text, in the form
+	// they are read from the database, so that they are parsed as the type
+	// of their field rather than used as SQL
+	Values []string `json:"v"`
+}
+
+var (
+	cursorKeyOnce sync.Once
+	cursorKey     []byte
+)
+
+// PaginateCursor returns a page of up to limit models that match the criteria,
+// using keyset pagination. Rows are ordered by ord, with ID as a final tiebreaker,
+// and each page is read by comparing the sort keys with those of the last row of
+// the page before, so pages stay consistent as rows are inserted.
+// Pass an empty cursor for the first page, and the Next or Prev cursor of a page
+// to move from it. Cursors are signed, and ErrInvalidCursor is returned for
+// cursors that have been altered or were created with a different ordering.
+// The fields of ord must be columns of the model, and must not be NULL.
+// @param db
+// @param cur
+// @param limit
+// @param ord
+// @param criteria
+// @return *CursorPage[T]
+// @return error
+func PaginateCursor[T Modeller](db *DB, cur string, limit int, ord *order.Builder, criteria ...interface{}) (*CursorPage[T], error) {
+	c, err := db.getCriteria(criteria)
+	if err != nil {
+		return nil, err
+	}
+	ms, next, prev, err := db.pageCursor(*new(T), cur, limit, ord, c)
+	if err != nil {
+		return nil, err
+	}
+	res := &CursorPage[T]{Items: make([]*T, 0, len(ms)), Next: next, Prev: prev}
+	for _, mdl := range ms {
+		res.Items = append(res.Items, utils.Ptr(mdl.(T)))
+	}
+	return res, nil
+}
+
+// pageCursor reads a page of models using keyset pagination, returning the
+// models along with the cursors of the next and previous pages
+// @param m
+// @param cur
+// @param limit
+// @param ord
+// @param c
+// @return []Modeller
+// @return string
+// @return string
+// @return error
+func (db *DB) pageCursor(m Modeller, cur string, limit int, ord *order.Builder, c *Criteria) ([]Modeller, string, string, error) {
+	if limit < 1 {
+		return nil, "", "", fmt.Errorf("invalid page size: %d", limit)
+	}
+	flds, n, err := db.tableTest(m)
+	if err != nil {
+		return nil, "", "", err
+	}
+	keys, err := sortFields(flds, ord)
+	if err != nil {
+		return nil, "", "", err
+	}
+	key := cursorID(n, keys)
+
+	var from *cursor
+	if cur != "" {
+		if from, err = db.decodeCursor(cur); err != nil {
+			return nil, "", "", err
+		}
+		if from.Key != key || len(from.Values) != len(keys) {
+			return nil, "", "", ErrInvalidCursor
+		}
+	}
+	back := from != nil && from.Back
+
+	// Rows before the boundary are read in reverse, and then restored to page order
+	ordStr := make([]string, 0, len(keys))
+	for _, k := range keys {
+		asc := k.Ascending != back
+		d := "ASC"
+		if !asc {
+			d = "DESC"
+		}
+		ordStr = append(ordStr, fmt.Sprintf("%s %s", db.mgr.IdentityString(k.Name), d))
+	}
+	pc := *c
+	if from != nil {
+		values, err := db.cursorLiterals(m, keys, flds, from.Values)
+		if err != nil {
+			return nil, "", "", err
+		}
+		pc = *pc.withWhere(keysetWhere(db.mgr, keys, values, back))
+	}
+	pc.Order = strings.Join(ordStr, ", ")
+	pc.Limit = limit + 1
+	pc.Offset = 0
+	pc.Cache = false
+
+	ms, err := db.fetch(m, &pc)
+	if err != nil {
+		return nil, "", "", err
+	}
+	more := len(ms) > limit
+	if more {
+		ms = ms[:limit]
+	}
+	if back {
+		slices.Reverse(ms)
+	}
+	if len(ms) == 0 {
+		return ms, "", "", nil
+	}
+
+	// Moving forward, there is a next page if more rows were read, and a previous
+	// page if a cursor was followed. Moving back, the reverse applies
+	next, prev := "", ""
+	if (!back && more) || back {
+		if next, err = db.encodeCursor(key, false, keys, flds, ms[len(ms)-1]); err != nil {
+			return nil, "", "", err
+		}
+	}
+	if (back && more) || (!back && from != nil) {
+		if prev, err = db.encodeCursor(key, true, keys, flds, ms[0]); err != nil {
+			return nil, "", "", err
+		}
+	}
+	return ms, next, prev, nil
+}
+
+// sortFields returns the fields of the ordering, followed by ID if the ordering
+// does not include it. Each field must be a column of the model
+func sortFields(flds []field, ord *order.Builder) ([]order.Field, error) {
+	keys := make([]order.Field, 0, 4)
+	if ord != nil {
+		keys = append(keys, ord.Fields()...)
+	}
+	hasID := false
+	for i, k := range keys {
+		if k.Relevance {
+			// Relevance is computed by the search, so there is no value to resume from
+			return nil, fmt.Errorf("cannot page by relevance: %s", k.Name)
+		}
+		f, ok := findField(flds, k.Name)
+		if !ok {
+			return nil, fmt.Errorf("unknown sort field: %s", k.Name)
+		}
+		keys[i].Name = f.name
+		hasID = hasID || f.name == "ID"
+	}
+	if !hasID {
+		keys = append(keys, order.Field{Name: "ID", Ascending: true})
+	}
+	return keys, nil
+}
+
+// findField returns the field with the column name, ignoring case
+func findField(flds []field, name string) (field, bool) {
+	for _, f := range flds {
+		if strings.EqualFold(f.name, name) {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// cursorID identifies the table and ordering of a cursor
+func cursorID(table string, keys []order.Field) string {
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		d := "+"
+		if !k.Ascending {
+			d = "-"
+		}
+		parts = append(parts, k.Name+d)
+	}
+	return table + ":" + strings.Join(parts, ",")
+}
+
+// keysetWhere returns the condition selecting the rows after the boundary row,
+// or before it if back is true. The comparison of the sort key tuples is expanded to
+// (a > x) OR (a = x AND b > y) ..., which supports mixed directions on all databases
+func keysetWhere(mgr Manager, keys []order.Field, values []string, back bool) string {
+	terms := make([]string, 0, len(keys))
+	for i, k := range keys {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, fmt.Sprintf("%s = %s", mgr.IdentityString(keys[j].Name), values[j]))
+		}
+		op := ">"
+		if k.Ascending == back {
+			op = "<"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s %s", mgr.IdentityString(k.Name), op, values[i]))
+		terms = append(terms, "("+strings.Join(parts, " AND ")+")")
+	}
+	return strings.Join(terms, " OR ")
+}
+
+// encodeCursor returns the signed cursor for the boundary row m
+func (db *DB) encodeCursor(key string, back bool, keys []order.Field, flds []field, m Modeller) (string, error) {
+	v := reflect.ValueOf(m)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	c := cursor{Key: key, Back: back, Values: make([]string, 0, len(keys))}
+	for _, k := range keys {
+		f, _ := findField(flds, k.Name)
+		vl, ok := f.get(v)
+		if !ok {
+			return "", fmt.Errorf("keyset pagination requires non-null sort keys: %s is null", f.name)
+		}
+		if _, ok := db.mgr.MakeValue(vl); !ok {
+			return "", fmt.Errorf("unsupported sort key: %s", f.name)
+		}
+		if t, ok := vl.(time.Time); ok {
+			c.Values = append(c.Values, utils.TimeToSQL(t))
+		} else {
+			c.Values = append(c.Values, fmt.Sprint(vl))
+		}
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(db.signCursor(payload)), nil
+}
+
+// cursorLiterals parses the sort keys of a cursor as the types of their
+// fields, and returns them as SQL literals
+func (db *DB) cursorLiterals(m Modeller, keys []order.Field, flds []field, values []string) ([]string, error) {
+	t := reflect.TypeOf(m)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	v := reflect.New(t).Elem()
+	res := make([]string, 0, len(keys))
+	for i, k := range keys {
+		f, _ := findField(flds, k.Name)
+		if !setFieldValue(f.value(v, true), f, values[i]) {
+			return nil, ErrInvalidCursor
+		}
+		vl, _ := f.get(v)
+		lit, ok := db.mgr.MakeValue(vl)
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+		res = append(res, lit)
+	}
+	return res, nil
+}
+
+// decodeCursor verifies the signature of a cursor and returns its content
+func (db *DB) decodeCursor(s string) (*cursor, error) {
+	p, sig, ok := strings.Cut(s, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(p)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, db.signCursor(payload)) {
+		return nil, ErrInvalidCursor
+	}
+	c := &cursor{}
+	if err := json.Unmarshal(payload, c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// signCursor returns the HMAC of the cursor payload
+func (db *DB) signCursor(payload []byte) []byte {
+	key := []byte(db.cfg.CursorSecret)
+	if len(key) == 0 {
+		cursorKeyOnce.Do(func() {
+			cursorKey = make([]byte, 32)
+			rand.Read(cursorKey)
+		})
+		key = cursorKey
+	}
+	h := hmac.New(sha256.New, key)
+	h.Write(payload)
+	return h.Sum(nil)
+}
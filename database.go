@@ -4,11 +4,11 @@
 package mud
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"iter"
-	"log"
 	"reflect"
 	"regexp"
 	"slices"
@@ -19,7 +19,6 @@ import (
 	"github.com/markoxley/mud/order"
 	"github.com/markoxley/mud/utils"
 	"github.com/markoxley/mud/where"
-	uuid "github.com/satori/go.uuid"
 
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/microsoft/go-mssqldb"
@@ -34,6 +33,20 @@ type DB struct {
 	db               *sql.DB
 	knownTables      []string
 	tableDef         map[string][]field
+	logger           Logger
+	instrumenters    []Instrumenter
+	cache            Cache
+	ctx              context.Context
+	replicas         []*Replica
+	balancer         Balancer
+	primary          bool
+	health           *healthCheck
+	tenant           string
+	tenants          *tenantPool
+	writes           *txWrites
+	// derived indicates the handle shares the connections of another, which
+	// owns them and closes them
+	derived bool
 }
 
 func New(config *Config) (*DB, error) {
@@ -63,30 +76,60 @@ func New(config *Config) (*DB, error) {
 		connectionString: cs,
 		knownTables:      make([]string, 0),
 		tableDef:         make(map[string][]field),
+		cache:            NewLRUCache(DefaultCacheSize),
+		balancer:         RoundRobin(),
+		tenants:          &tenantPool{dbs: make(map[string]*DB)},
+		writes:           newTxWrites(),
 	}
-	svr, err := db.connect()
+	svr, err := db.connect(config, cs)
 	if err != nil {
 		return nil, err
 	}
 	db.db = svr
+	if err := db.openReplicas(); err != nil {
+		db.disconnect(svr)
+		return nil, err
+	}
 	mgr.SetDB(db)
 	return db, nil
 }
 
+// Close disconnects from the database, its replicas and the databases of its
+// tenants. Closing a handle returned by WithContext, UsePrimary or ForTenant
+// does nothing, as the connections belong to the handle it was derived from.
 func (db *DB) Close() {
+	if db.derived {
+		return
+	}
 	if db.db != nil {
 		db.disconnect(db.db)
 	}
+	db.closeReplicas()
+	db.closeTenants()
 }
 
 // connect attempts to connect to the database
+// @param cfg
+// @param cs
 // @return *sql.DB
 // @return error
-func (db *DB) connect() (*sql.DB, error) {
-	tdb, err := sql.Open(db.dbtype, db.connectionString)
+func (db *DB) connect(cfg *Config, cs string) (*sql.DB, error) {
+	tdb, err := sql.Open(db.dbtype, cs)
 	if err != nil {
 		return nil, err
 	}
+	if cfg.MaxOpenConns > 0 {
+		tdb.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns != 0 {
+		tdb.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		tdb.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		tdb.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
 	return tdb, nil
 }
 
@@ -103,13 +146,16 @@ func (db *DB) disconnect(d *sql.DB) {
 // @return *sql.Tx
 // @return error
 func (db *DB) beginTransaction(d *sql.DB) (*sql.Tx, error) {
-	return d.Begin()
+	tx, err := d.BeginTx(db.context(), nil)
+	db.replicaFailed(d, err)
+	return tx, err
 }
 
 // commitTransaction commites the transaction to the database
 // @param tx
 func (db *DB) CommitTransaction(tx *sql.Tx) error {
 	if tx != nil {
+		defer db.endWrites(tx)
 		return tx.Commit()
 	}
 	return nil
@@ -117,6 +163,7 @@ func (db *DB) CommitTransaction(tx *sql.Tx) error {
 
 func (db *DB) RollbackTransaction(tx *sql.Tx) error {
 	if tx != nil {
+		defer db.endWrites(tx)
 		return tx.Rollback()
 	}
 	return nil
@@ -128,28 +175,23 @@ func (db *DB) BeginTransaction() (*sql.Tx, error) {
 
 // selectScalar atempts to execute the specified query and returns
 // the value of the first column of the first row
-// @param q
+// @param op
 // @return interface{}
 // @return bool
-func (db *DB) selectScalar(q string, tx ...*sql.Tx) (interface{}, bool) {
+func (db *DB) selectScalar(op Operation, tx ...*sql.Tx) (interface{}, bool) {
 	var qtx *sql.Tx
 	var err error
 	if len(tx) > 0 {
 		qtx = tx[0]
 	} else if !db.cfg.DisabledTransactions {
-		qtx, err = db.beginTransaction(db.db)
+		qtx, err = db.beginTransaction(db.pool(op))
 		if err != nil {
 			return nil, false
 		}
 		defer db.CommitTransaction(qtx)
 	}
 
-	var res *sql.Rows
-	if qtx != nil {
-		res, err = qtx.Query(q)
-	} else {
-		res, err = db.db.Query(q)
-	}
+	res, err := db.query(qtx, op)
 	if err != nil {
 		return nil, false
 	}
@@ -166,32 +208,81 @@ func (db *DB) selectScalar(q string, tx ...*sql.Tx) (interface{}, bool) {
 
 // selectQuery attempts to execute the query passed, returning
 // a slice of the type specified by the type parameter
-// @param q
+// @param op
 // @return []*T
 // @return bool
-func (db *DB) selectQuery(m Modeller, q string, tx ...*sql.Tx) ([]Modeller, bool) {
+func (db *DB) selectQuery(m Modeller, op Operation, tx ...*sql.Tx) ([]Modeller, bool) {
+	e, ok := db.selectEntry(op, tx...)
+	if !ok {
+		return nil, false
+	}
+	return db.hydrate(m, e)
+}
+
+// selectEntry attempts to execute the query passed, returning
+// the columns and rows of the result
+// @param op
+// @return *CacheEntry
+// @return bool
+func (db *DB) selectEntry(op Operation, tx ...*sql.Tx) (*CacheEntry, bool) {
 	var qtx *sql.Tx
 	var err error
 	if len(tx) > 0 {
 		qtx = tx[0]
 	} else if !db.cfg.DisabledTransactions {
-		qtx, err = db.beginTransaction(db.db)
+		qtx, err = db.beginTransaction(db.pool(op))
 		if err != nil {
 			return nil, false
 		}
 		defer db.CommitTransaction(qtx)
 	}
-	var res *sql.Rows
-	if qtx != nil {
-		res, err = qtx.Query(q)
-	} else {
-		res, err = db.db.Query(q)
-	}
+	res, err := db.query(qtx, op)
 	if err != nil {
 		return nil, false
 	}
 	defer res.Close()
-	return db.populateModel(m, res)
+	e, err := readRows(res)
+	if err != nil {
+		return nil, false
+	}
+	return e, true
+}
+
+// readRows reads the columns and all rows of the result set
+// @param r
+// @return *CacheEntry
+// @return error
+func readRows(r *sql.Rows) (*CacheEntry, error) {
+	cc, err := r.Columns()
+	if err != nil {
+		return nil, err
+	}
+	e := &CacheEntry{Columns: cc, Rows: make([][]*string, 0, 100)}
+	for r.Next() {
+		cols, err := readRow(r, len(cc))
+		if err != nil {
+			return nil, err
+		}
+		e.Rows = append(e.Rows, cols)
+	}
+	return e, r.Err()
+}
+
+// readRow reads the current row of the result set as strings
+// @param r
+// @param n
+// @return []*string
+// @return error
+func readRow(r *sql.Rows, n int) ([]*string, error) {
+	cols := make([]*string, n)
+	vls := make([]interface{}, n)
+	for i := range cols {
+		vls[i] = &cols[i]
+	}
+	if err := r.Scan(vls...); err != nil {
+		return nil, err
+	}
+	return cols, nil
 }
 
 // populateModel creates a new slice of models of the type
@@ -200,299 +291,144 @@ func (db *DB) selectQuery(m Modeller, q string, tx ...*sql.Tx) ([]Modeller, bool
 // @return []*T
 // @return bool
 func (db *DB) populateModel(m Modeller, r *sql.Rows) ([]Modeller, bool) {
-	s := reflect.TypeOf(m)
-
-	res := make([]Modeller, 0, 100)
-	ok := true
-
-	// Get the column count
-	cc, _ := r.Columns()
+	e, err := readRows(r)
+	if err != nil {
+		return nil, false
+	}
+	return db.hydrate(m, e)
+}
 
-	// Make them all uppercase
-	for i := range cc {
-		cc[i] = strings.ToUpper(cc[i])
+// hydrate creates a new slice of models of the type of m
+// and populates the fields from the rows of the result
+// @param m
+// @param e
+// @return []Modeller
+// @return bool
+func (db *DB) hydrate(m Modeller, e *CacheEntry) ([]Modeller, bool) {
+	s := reflect.TypeOf(m)
+	isPtr := s.Kind() == reflect.Pointer
+	if isPtr {
+		s = s.Elem()
 	}
 
-	// Get the fields of the model and build a map of them
-	//t := reflect.TypeOf(*m)
-	mdl := reflect.New(s).Interface().(Modeller)
-	flds, ok := db.tableDef[GetTableName(mdl)]
+	fMap, cc, ok := db.columnMap(m, e.Columns)
 	if !ok {
 		return nil, false
 	}
-	fMap := make(map[string]field, len(flds))
-	for _, f := range flds {
-		fMap[strings.ToUpper(f.name)] = f
-	}
-
-	cols := make([]*string, len(cc))
-	vls := make([]interface{}, len(cc))
 
-	rowCount := 0
-	for r.Next() {
-		// s := reflect.TypeOf(m)
+	flds := db.tableDef[GetTableName(m)]
+	res := make([]Modeller, 0, len(e.Rows))
+	for _, row := range e.Rows {
 		v := reflect.New(s)
-
-		for i := range cols {
-			vls[i] = &cols[i]
-		}
-		r.Scan(vls...)
-
-		for i := 0; i < len(cc); i++ {
-			if cols[i] == nil {
-				continue
-			}
-			if cc[i] == "ID" {
-				tmpID := cols[i]
-				v.Elem().FieldByName("ID").Set(reflect.ValueOf(tmpID))
-			} else if cc[i] == "CREATEDATE" {
-				if cols[i] != nil {
-					if tm, ok := utils.SQLToTime(*cols[i]); ok {
-						tmpCreate := *tm
-						v.Elem().FieldByName("CreateDate").Set(reflect.ValueOf(tmpCreate))
-					}
-				}
-			} else if cc[i] == "LASTUPDATE" {
-				if cols[i] != nil {
-					if tm, ok := utils.SQLToTime(*cols[i]); ok {
-						tmpUpdate := *tm
-						v.Elem().FieldByName("LastUpdate").Set(reflect.ValueOf(tmpUpdate))
-					}
-				}
-			} else if cc[i] == "DELETEDATE" {
-				if cols[i] != nil {
-					if tm, ok := utils.SQLToTime(*cols[i]); ok {
-						tmpDeleted := tm
-						v.Elem().FieldByName("DeleteDate").Set(reflect.ValueOf(tmpDeleted))
-					}
-				}
-			} else if fld, ok := fMap[cc[i]]; ok {
-				switch fld.fType {
-				case tInt, tLong:
-					if fld.unsigned {
-						if val, err := strconv.ParseUint(*cols[i], 10, 64); err != nil {
-							if fld.allowNull {
-								v.Elem().FieldByName(fld.name).Set(reflect.ValueOf(val))
-							} else {
-								v.Elem().FieldByName(fld.name).SetUint(val)
-							}
-						}
-					} else {
-						if val, err := strconv.ParseInt(*cols[i], 10, 64); err == nil {
-							if fld.allowNull {
-								v.Elem().FieldByName(fld.name).Set(reflect.ValueOf(val))
-							} else {
-								v.Elem().FieldByName(fld.name).SetInt(val)
-							}
-						}
-					}
-				case tBool:
-					if val, err := strconv.ParseInt(*cols[i], 10, 64); err == nil {
-						if fld.allowNull {
-							v.Elem().FieldByName(fld.name).Elem().SetBool(val == 1)
-						} else {
-							v.Elem().FieldByName(fld.name).SetBool(val == 1)
-						}
-					}
-				case tDecimal, tFloat, tDouble:
-					if val, err := strconv.ParseFloat(*cols[i], 64); err == nil {
-						if fld.allowNull {
-							v.Elem().FieldByName(fld.name).Set(reflect.ValueOf(val))
-						} else {
-							v.Elem().FieldByName(fld.name).SetFloat(val)
-						}
-					}
-				case tDateTime:
-					if cols[i] != nil {
-						if val, ok := utils.SQLToTime(*cols[i]); ok {
-							if fld.allowNull {
-								v.Elem().FieldByName(fld.name).Set(reflect.ValueOf(val))
-							} else {
-								v.Elem().FieldByName(fld.name).Set(reflect.ValueOf(*val))
-							}
-						}
-					}
-				case tChar:
-					st := *cols[i]
-					strVal := st[:1]
-					if fld.allowNull {
-						v.Elem().FieldByName(fld.name).Set(reflect.ValueOf(&strVal))
-					} else {
-						v.Elem().FieldByName(fld.name).SetString(strVal)
-					}
-				case tString:
-					if fld.allowNull {
-						strVal := *cols[i]
-						v.Elem().FieldByName(fld.name).Set(reflect.ValueOf(&strVal))
-					} else {
-						v.Elem().FieldByName(fld.name).SetString(*cols[i])
-					}
-				case tUUID:
-					if fld.allowNull {
-						strVal := *cols[i]
-						v.Elem().FieldByName(fld.name).Set(reflect.ValueOf(&strVal))
-					} else {
-						v.Elem().FieldByName(fld.name).SetString(*cols[i])
-					}
-				}
-			}
-
+		applyRow(v.Elem(), fMap, cc, row)
+		db.doRestore(v.Interface().(Modeller))
+		takeSnapshot(v.Interface().(Modeller), flds)
+		var newObj Modeller
+		if isPtr {
+			newObj = v.Interface().(Modeller)
+		} else {
+			newObj = v.Elem().Interface().(Modeller)
 		}
-		newObj := v.Elem().Interface().(Modeller)
-		db.doRestore(newObj)
 		res = append(res, newObj)
-		rowCount++
-
 	}
-	return res[:rowCount], ok
+	return res, true
 }
 
-func (db *DB) populateSingle(m Modeller, r *sql.Rows) bool {
-	s := reflect.TypeOf(m)
-	ok := true
-
-	// Get the column count
-	cc, _ := r.Columns()
-
-	// Make them all uppercase
-	for i := range cc {
-		cc[i] = strings.ToUpper(cc[i])
+// populateSingle populates the model pointed to by m from the current row
+// @param m
+// @param r
+// @return error
+func (db *DB) populateSingle(m Modeller, r *sql.Rows) error {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Pointer {
+		return errors.New("model must be a pointer")
 	}
+	cc, err := r.Columns()
+	if err != nil {
+		return err
+	}
+	fMap, cc, ok := db.columnMap(m, cc)
+	if !ok {
+		return errors.New("table definition not found")
+	}
+	cols, err := readRow(r, len(cc))
+	if err != nil {
+		return err
+	}
+	applyRow(v.Elem(), fMap, cc, cols)
+	db.doRestore(m)
+	takeSnapshot(m, db.tableDef[GetTableName(m)])
+	return nil
+}
 
-	// Get the fields of the model and build a map of them
-	//t := reflect.TypeOf(*m)
-	mdl := reflect.New(s).Interface().(Modeller)
-	flds, ok := db.tableDef[GetTableName(mdl)]
+// columnMap returns the fields of the model keyed by their upper case column
+// name, along with the upper case names of the columns of the result set
+// @param m
+// @param columns
+// @return map[string]field
+// @return []string
+// @return bool
+func (db *DB) columnMap(m Modeller, columns []string) (map[string]field, []string, bool) {
+	flds, ok := db.tableDef[GetTableName(m)]
 	if !ok {
-		return false
+		return nil, nil, false
 	}
 	fMap := make(map[string]field, len(flds))
 	for _, f := range flds {
 		fMap[strings.ToUpper(f.name)] = f
 	}
 
-	cols := make([]*string, len(cc))
-	vls := make([]interface{}, len(cc))
-
-	v := reflect.New(s)
-
-	for i := range cols {
-		vls[i] = &cols[i]
+	cc := make([]string, len(columns))
+	for i := range columns {
+		cc[i] = strings.ToUpper(columns[i])
 	}
-	r.Scan(vls...)
+	return fMap, cc, true
+}
 
-	for i := 0; i < len(cc); i++ {
+// applyRow sets the fields of the struct value v from the values of a row
+// @param v
+// @param fMap
+// @param cc
+// @param cols
+func applyRow(v reflect.Value, fMap map[string]field, cc []string, cols []*string) {
+	for i := range cc {
 		if cols[i] == nil {
 			continue
 		}
-		if cc[i] == "ID" {
-			tmpID := cols[i]
-			v.Elem().FieldByName("ID").Set(reflect.ValueOf(tmpID))
-		} else if cc[i] == "CREATEDATE" {
-			if cols[i] != nil {
-				if tm, ok := utils.SQLToTime(*cols[i]); ok {
-					tmpCreate := *tm
-					v.Elem().FieldByName("CreateDate").Set(reflect.ValueOf(tmpCreate))
-				}
-			}
-		} else if cc[i] == "LASTUPDATE" {
-			if cols[i] != nil {
-				if tm, ok := utils.SQLToTime(*cols[i]); ok {
-					tmpUpdate := *tm
-					v.Elem().FieldByName("LastUpdate").Set(reflect.ValueOf(tmpUpdate))
-				}
-			}
-		} else if cc[i] == "DELETEDATE" {
-			if cols[i] != nil {
-				if tm, ok := utils.SQLToTime(*cols[i]); ok {
-					tmpDeleted := tm
-					v.Elem().FieldByName("DeleteDate").Set(reflect.ValueOf(tmpDeleted))
-				}
-			}
-		} else if fld, ok := fMap[cc[i]]; ok {
-			switch fld.fType {
-			case tInt, tLong:
-				if fld.unsigned {
-					if val, err := strconv.ParseUint(*cols[i], 10, 64); err != nil {
-						if fld.allowNull {
-							v.Elem().FieldByName(fld.name).Set(reflect.ValueOf(val))
-						} else {
-							v.Elem().FieldByName(fld.name).SetUint(val)
-						}
-					}
-				} else {
-					if val, err := strconv.ParseInt(*cols[i], 10, 64); err == nil {
-						if fld.allowNull {
-							v.Elem().FieldByName(fld.name).Set(reflect.ValueOf(val))
-						} else {
-							v.Elem().FieldByName(fld.name).SetInt(val)
-						}
-					}
-				}
-			case tBool:
-				if val, err := strconv.ParseInt(*cols[i], 10, 64); err == nil {
-					if fld.allowNull {
-						v.Elem().FieldByName(fld.name).Elem().SetBool(val == 1)
-					} else {
-						v.Elem().FieldByName(fld.name).SetBool(val == 1)
-					}
-				}
-			case tDecimal, tFloat, tDouble:
-				if val, err := strconv.ParseFloat(*cols[i], 64); err == nil {
-					if fld.allowNull {
-						v.Elem().FieldByName(fld.name).Set(reflect.ValueOf(val))
-					} else {
-						v.Elem().FieldByName(fld.name).SetFloat(val)
-					}
-				}
-			case tDateTime:
-				if cols[i] != nil {
-					if val, ok := utils.SQLToTime(*cols[i]); ok {
-						if fld.allowNull {
-							v.Elem().FieldByName(fld.name).Set(reflect.ValueOf(val))
-						} else {
-							v.Elem().FieldByName(fld.name).Set(reflect.ValueOf(*val))
-						}
-					}
-				}
-			case tChar:
-				st := *cols[i]
-				strVal := st[:1]
-				if fld.allowNull {
-					v.Elem().FieldByName(fld.name).Set(reflect.ValueOf(&strVal))
-				} else {
-					v.Elem().FieldByName(fld.name).SetString(strVal)
-				}
-			case tString:
-				if fld.allowNull {
-					strVal := *cols[i]
-					v.Elem().FieldByName(fld.name).Set(reflect.ValueOf(&strVal))
-				} else {
-					v.Elem().FieldByName(fld.name).SetString(*cols[i])
-				}
-			case tUUID:
-				if fld.allowNull {
-					strVal := *cols[i]
-					v.Elem().FieldByName(fld.name).Set(reflect.ValueOf(&strVal))
-				} else {
-					v.Elem().FieldByName(fld.name).SetString(*cols[i])
-				}
-			}
+		if fld, ok := fMap[cc[i]]; ok {
+			setFieldValue(fld.value(v, true), fld, *cols[i])
 		}
-
 	}
-	newObj := v.Elem().Interface().(Modeller)
-	db.doRestore(newObj)
-	mdl = newObj
-	//	}
-	return ok
 }
+
 func (db *DB) doRestore(m Modeller) {
 	if r, ok := m.(Restorer); ok {
 		r.Restore(db.mgr)
 	}
 }
 
+// setID sets the ID of the specified model
+// @param m
+// @param id
+func (db *DB) setID(m Modeller, id string) {
+	v := reflect.ValueOf(m)
+	rv := reflect.New(reflect.TypeOf(id))
+	rv.Elem().Set(reflect.ValueOf(id))
+	v.Elem().FieldByName("ID").Set(rv)
+}
+
+// updateDates updates the date fields of the specified model
+// @param m
+// @param createdate
+// @param updatedate
+// @param deletedate
+func (db *DB) updateDates(m Modeller, createdate time.Time, updatedate time.Time, deletedate *time.Time) {
+	v := reflect.ValueOf(m)
+	v.Elem().FieldByName("CreateDate").Set(reflect.ValueOf(createdate))
+	v.Elem().FieldByName("LastUpdate").Set(reflect.ValueOf(updatedate))
+	v.Elem().FieldByName("DeleteDate").Set(reflect.ValueOf(deletedate))
+}
+
 // updateModel updates the date fields of the specified model
 // @param m
 // @param id
@@ -514,9 +450,18 @@ func (db *DB) updateModel(m Modeller, id string, createdate time.Time, updatedat
 }
 
 // executeQuery attempts to execute the passed sql query
-// @param q
+// @param op
 // @return bool
-func (db *DB) executeQuery(q string, tx ...*sql.Tx) error {
+func (db *DB) executeQuery(op Operation, tx ...*sql.Tx) error {
+	_, err := db.execute(op, tx...)
+	return err
+}
+
+// execute attempts to execute the passed sql query, returning the result
+// @param op
+// @return sql.Result
+// @return error
+func (db *DB) execute(op Operation, tx ...*sql.Tx) (sql.Result, error) {
 	var qtx *sql.Tx
 	var err error
 	if len(tx) > 0 {
@@ -526,14 +471,85 @@ func (db *DB) executeQuery(q string, tx ...*sql.Tx) error {
 		defer db.CommitTransaction(qtx)
 	}
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return db.exec(qtx, op)
+}
+
+// exec executes a statement on the transaction, or on the database if the
+// transaction is nil, and reports it to the logger and instrumenters
+// @param qtx
+// @param op
+// @return sql.Result
+// @return error
+func (db *DB) exec(qtx *sql.Tx, op Operation) (sql.Result, error) {
+	ctx, done := db.startOperation(op)
+	var res sql.Result
+	var err error
 	if qtx != nil {
-		_, err = qtx.Exec(q)
+		res, err = qtx.ExecContext(ctx, op.Statement)
+		db.written(qtx, op)
 	} else {
-		_, err = db.db.Exec(q)
+		res, err = db.db.ExecContext(ctx, op.Statement)
+	}
+	rows := int64(-1)
+	if err == nil {
+		if n, rerr := res.RowsAffected(); rerr == nil {
+			rows = n
+		}
+	}
+	done(rows, err)
+	return res, err
+}
+
+// query executes a query on the transaction, or on the database if the
+// transaction is nil, and reports it to the logger and instrumenters
+// @param qtx
+// @param op
+// @return *sql.Rows
+// @return error
+func (db *DB) query(qtx *sql.Tx, op Operation) (*sql.Rows, error) {
+	ctx, done := db.startOperation(op)
+	var res *sql.Rows
+	var err error
+	if qtx != nil {
+		res, err = qtx.QueryContext(ctx, op.Statement)
+	} else {
+		d := db.pool(op)
+		res, err = d.QueryContext(ctx, op.Statement)
+		db.replicaFailed(d, err)
+	}
+	done(-1, err)
+	return res, err
+}
+
+// startOperation notifies the instrumenters that an operation is starting.
+// The returned function reports the outcome to the logger and instrumenters
+// @param op
+// @return context.Context
+// @return func(int64, error)
+func (db *DB) startOperation(op Operation) (context.Context, func(int64, error)) {
+	ctx := db.context()
+	for _, i := range db.instrumenters {
+		ctx = i.Start(ctx, op)
+	}
+	start := time.Now()
+	return ctx, func(rows int64, err error) {
+		d := time.Since(start)
+		if db.cache != nil {
+			db.invalidateCache(op)
+		}
+		if db.logger != nil {
+			db.logger.LogQuery(ctx, QueryEvent{Statement: op.Statement, Duration: d, Rows: rows, Err: err})
+		}
+		if len(db.instrumenters) == 0 {
+			return
+		}
+		res := OperationResult{Duration: d, Rows: rows, Err: err, Class: ClassifyError(err)}
+		for i := len(db.instrumenters) - 1; i >= 0; i-- {
+			db.instrumenters[i].End(ctx, op, res)
+		}
 	}
-	return err
 }
 
 // tableExists tests for the existence of the specified table
@@ -545,7 +561,7 @@ func (db *DB) tableExists(t string) bool {
 	}
 
 	qry := db.mgr.TableExistsQuery(t)
-	if _, ok := db.selectScalar(qry); ok {
+	if _, ok := db.selectScalar(Operation{Name: OpTableExists, Table: t, Statement: qry}); ok {
 		db.knownTables = append(db.knownTables, t)
 		return true
 	}
@@ -562,7 +578,7 @@ func (db *DB) tableExists(t string) bool {
 // @param sql
 // @return bool
 func (db *DB) RawExecute(sql string, tx ...*sql.Tx) error {
-	return db.executeQuery(sql, tx...)
+	return db.executeQuery(Operation{Name: OpRaw, Statement: sql}, tx...)
 }
 
 // RawScalar exeutes a raw sql statement that returns a single value
@@ -571,7 +587,7 @@ func (db *DB) RawExecute(sql string, tx ...*sql.Tx) error {
 // @return interface{}
 // @return bool
 func (db *DB) RawScalar(sql string, tx ...*sql.Tx) (interface{}, bool) {
-	return db.selectScalar(sql, tx...)
+	return db.selectScalar(Operation{Name: OpRaw, Statement: sql}, tx...)
 }
 
 // RawSelect executes a raw sql statement on the database
@@ -579,6 +595,15 @@ func (db *DB) RawScalar(sql string, tx ...*sql.Tx) (interface{}, bool) {
 // @param sql
 // @return []map
 func (db *DB) RawSelect(qry string, tx ...*sql.Tx) ([]map[string]interface{}, error) {
+	return db.rawSelect(Operation{Name: OpRaw, Statement: qry}, tx...)
+}
+
+// rawSelect executes a query on the database, returning the rows as maps of
+// column names to string pointers
+// @param op
+// @return []map
+// @return error
+func (db *DB) rawSelect(op Operation, tx ...*sql.Tx) ([]map[string]interface{}, error) {
 	var qtx *sql.Tx
 	var err error
 	if len(tx) > 0 {
@@ -590,12 +615,7 @@ func (db *DB) RawSelect(qry string, tx ...*sql.Tx) ([]map[string]interface{}, er
 		}
 		defer db.CommitTransaction(qtx)
 	}
-	var res *sql.Rows
-	if qtx != nil {
-		res, err = qtx.Query(qry)
-	} else {
-		res, err = db.db.Query(qry)
-	}
+	res, err := db.query(qtx, op)
 	if err != nil {
 		return nil, err
 	}
@@ -629,6 +649,15 @@ func (db *DB) RawSelect(qry string, tx ...*sql.Tx) ([]map[string]interface{}, er
 // @return *Criteria
 // @return error
 func (db *DB) getCriteria(criteria []interface{}) (*Criteria, error) {
+	return criteriaOf(criteria)
+}
+
+// criteriaOf returns the criteria given as a Criteria, a where or order
+// builder, an ID or a WHERE condition
+// @param criteria
+// @return *Criteria
+// @return error
+func criteriaOf(criteria []interface{}) (*Criteria, error) {
 	for _, cr := range criteria {
 		if cr == nil {
 			continue
@@ -662,45 +691,89 @@ func (db *DB) getCriteria(criteria []interface{}) (*Criteria, error) {
 	return &Criteria{}, nil
 }
 
+// Range returns an iterator over the models that match the criteria.
+// Iteration stops silently on any error; use RangeErr to receive errors
+// @param mdl
+// @param criteria
+// @return iter.Seq[Modeller]
 func (db *DB) Range(mdl Modeller, criteria ...interface{}) iter.Seq[Modeller] {
 	return func(yield func(Modeller) bool) {
+		for m, err := range db.RangeErr(mdl, criteria...) {
+			if err != nil || !yield(m) {
+				return
+			}
+		}
+	}
+}
+
+// RangeErr returns an iterator over the models that match the criteria.
+// Rows are read one at a time as the iterator advances. Any failure, including
+// cancellation of the context of the database handle, is yielded as an error
+// with a nil model, after which iteration ends
+// @param mdl
+// @param criteria
+// @return iter.Seq2[Modeller, error]
+func (db *DB) RangeErr(mdl Modeller, criteria ...interface{}) iter.Seq2[Modeller, error] {
+	return func(yield func(Modeller, error) bool) {
 		c, err := db.getCriteria(criteria)
 		if err != nil {
+			yield(nil, err)
 			return
 		}
-		t := reflect.TypeOf(mdl)
-		n := t.Name()
-		_, _, err = db.tableTest(mdl)
+		flds, n, err := db.tableTest(mdl)
 		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if c, err = db.scope(mdl, flds, c); err != nil {
+			yield(nil, err)
 			return
 		}
 		s := fmt.Sprintf("select * from %s", db.mgr.IdentityString(n))
 		s += c.String(db.mgr)
+		op := Operation{Name: OpSelect, Table: n, Statement: s}
 
 		var qtx *sql.Tx
 		if !db.cfg.DisabledTransactions {
-			qtx, err = db.beginTransaction(db.db)
+			qtx, err = db.beginTransaction(db.pool(op))
 			if err != nil {
+				yield(nil, err)
 				return
 			}
 			defer db.CommitTransaction(qtx)
 		}
-		var res *sql.Rows
-		if qtx != nil {
-			res, err = qtx.Query(s)
-		} else {
-			res, err = db.db.Query(s)
-		}
+		res, err := db.query(qtx, op)
 		if err != nil {
+			yield(nil, err)
 			return
 		}
 		defer res.Close()
+
+		t := reflect.TypeOf(mdl)
+		isPtr := t.Kind() == reflect.Pointer
+		if isPtr {
+			t = t.Elem()
+		}
+		ctx := db.context()
 		for res.Next() {
-			r := reflect.New(reflect.TypeOf(mdl).Elem()).Interface().(Modeller)
-			db.populateSingle(r, res)
-			if !yield(r) {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
 				return
 			}
+			r := reflect.New(t).Interface().(Modeller)
+			if err := db.populateSingle(r, res); err != nil {
+				yield(nil, err)
+				return
+			}
+			if !isPtr {
+				r = reflect.ValueOf(r).Elem().Interface().(Modeller)
+			}
+			if !yield(r, nil) {
+				return
+			}
+		}
+		if err := res.Err(); err != nil {
+			yield(nil, err)
 		}
 	}
 }
@@ -715,17 +788,48 @@ func (db *DB) Fetch(mdl Modeller, criteria ...interface{}) ([]Modeller, error) {
 	if err != nil {
 		return nil, err
 	}
+	return db.fetch(mdl, c)
+}
 
-	t := reflect.TypeOf(mdl)
-	n := t.Name()
-	_, _, err = db.tableTest(mdl)
+// fetch returns the models from the database that match the criteria.
+// The cache is only used outside of transactions
+// @param mdl
+// @param c
+// @param tx
+// @return []Modeller
+// @return error
+func (db *DB) fetch(mdl Modeller, c *Criteria, tx ...*sql.Tx) ([]Modeller, error) {
+	flds, n, err := db.tableTest(mdl)
 	if err != nil {
 		return nil, err
 	}
+	if c, err = db.scope(mdl, flds, c); err != nil {
+		return nil, err
+	}
 
 	s := fmt.Sprintf("SELECT * FROM %s", db.mgr.IdentityString(n))
 	s += c.String(db.mgr)
-	res, ok := db.selectQuery(mdl, s)
+	op := Operation{Name: OpSelect, Table: n, Statement: s}
+	// Results of subqueries are not cached, as writes to their tables would
+	// not invalidate them
+	if !c.Cache || db.cache == nil || len(tx) > 0 || c.subqueries {
+		res, ok := db.selectQuery(mdl, op, tx...)
+		if !ok {
+			return nil, errors.New("error selecting data")
+		}
+		return res, nil
+	}
+
+	key := cacheKey(n, s)
+	e, ok := db.cache.Get(key)
+	if !ok {
+		gen := db.generation(n)
+		if e, ok = db.selectEntry(op); !ok {
+			return nil, errors.New("error selecting data")
+		}
+		db.storeCache(n, key, e, cacheTTL(mdl, c), gen)
+	}
+	res, ok := db.hydrate(mdl, e)
 	if !ok {
 		return nil, errors.New("error selecting data")
 	}
@@ -761,102 +865,239 @@ func (db *DB) Count(m Modeller, criteria ...interface{}) int {
 	if err != nil {
 		return -1
 	}
-	t := GetTableName(m)
 	_, _, err = db.tableTest(m)
 	if err != nil {
 		return -1
 	}
-	s := fmt.Sprintf("Select Count(*) from %s", db.mgr.IdentityString(t))
-	if c != nil {
-		s += c.WhereString(db.mgr)
-	}
-	if i, ok := db.selectScalar(s); ok {
-		if vl, vlok := i.(string); vlok {
-			if res, err := strconv.Atoi(vl); err == nil {
-				return res
-			}
-		}
+	res, err := db.count(m, c)
+	if err != nil {
+		return 0
 	}
-	return 0
+	return res
+}
 
+// count returns the number of rows in the database that match the criteria
+// @param m
+// @param c
+// @param tx
+// @return int
+// @return error
+func (db *DB) count(m Modeller, c *Criteria, tx ...*sql.Tx) (int, error) {
+	flds, t, err := db.tableTest(m)
+	if err != nil {
+		return 0, err
+	}
+	if c, err = db.scope(m, flds, c); err != nil {
+		return 0, err
+	}
+	s := fmt.Sprintf("Select Count(*) from %s", db.mgr.IdentityString(t))
+	s += c.WhereString(db.mgr)
+	i, ok := db.selectScalar(Operation{Name: OpCount, Table: t, Statement: s}, tx...)
+	if !ok {
+		return 0, errors.New("error counting data")
+	}
+	vl, _ := i.(string)
+	return strconv.Atoi(vl)
 }
 
-func (db *DB) insertCommand(m Modeller) (string, error) {
+// insertCommand returns the SQL command to insert the model into the
+// database, along with the fields whose values the database will supply.
+// For models with auto-incrementing keys, returning reports whether the
+// command returns the generated key
+// @param m
+// @return string
+// @return []field
+// @return bool
+// @return error
+func (db *DB) insertCommand(m Modeller) (string, []field, bool, error) {
 	//t := reflect.TypeOf(m)
 	//n := getTableName(m)
 	flds, n, err := db.tableTest(m)
 	if err != nil {
-		return "", err
+		return "", nil, false, err
 	}
-	uid := uuid.NewV4()
-
-	fds := "ID, CreateDate, LastUpdate"
+	if err := db.tenantModel(m, flds); err != nil {
+		return "", nil, false, err
+	}
+	readBack := make([]field, 0)
 	now := time.Now()
-	db.updateModel(m, uid.String(), now, now, nil)
-	q := fmt.Sprintf("'%s', '%s', '%s'", uid, utils.TimeToSQL(now), utils.TimeToSQL(now))
+	fds := "CreateDate, LastUpdate"
+	q := fmt.Sprintf("'%s', '%s'", utils.TimeToSQL(now), utils.TimeToSQL(now))
+
+	autoInc := isAutoIncrement(m)
+	if autoInc {
+		db.updateDates(m, now, now, nil)
+	} else {
+		uid, err := keyGenerator(m).NewID()
+		if err != nil {
+			return "", nil, false, err
+		}
+		db.updateModel(m, uid, now, now, nil)
+		id, _ := db.mgr.MakeValue(uid)
+		fds = "ID, " + fds
+		q = id + ", " + q
+	}
 	v := reflect.ValueOf(m)
 	for _, f := range flds {
 		if f.name == "ID" || f.name == "CreateDate" || f.name == "LastUpdate" || f.name == "DeleteDate" {
 			continue
 		}
-		vi := v.Elem().FieldByName(f.name)
+		// Columns that are never written are read back after the insert
+		if !f.insertable() {
+			readBack = append(readBack, f)
+			continue
+		}
 
-		if f.allowNull {
-			if vi.IsNil() {
-				continue
+		// Leave defaulted columns to the database when no value is set
+		if f.hasDefault() && f.unset(v.Elem()) {
+			if f.dbDefault != "" {
+				readBack = append(readBack, f)
 			}
-			vi = vi.Elem()
+			continue
 		}
 
-		vf := vi.Interface()
+		vf, ok := f.get(v.Elem())
+		if !ok {
+			continue
+		}
 
-		if vl, ok := utils.MakeValue(vf); ok {
+		if vl, ok := db.mgr.MakeValue(vf); ok {
 			fds += fmt.Sprintf(", %s", db.mgr.IdentityString(f.name))
 			q += fmt.Sprintf(", %s", vl)
 		}
 	}
 
+	if autoInc {
+		def, returning := db.mgr.InsertReturning(db.mgr.IdentityString(n), fds, q, db.mgr.IdentityString("ID"))
+		return def, readBack, returning, nil
+	}
 	def := fmt.Sprintf("INSERT INTO %s (%s) VALUES(%s)", db.mgr.IdentityString(n), fds, q)
-	return def, nil
+	return def, readBack, false, nil
+}
+
+// insertIdentity executes the insert command of a model with an
+// auto-incrementing key and returns the key generated by the database
+// @param op
+// @param returning
+// @param tx
+// @return string
+// @return error
+func (db *DB) insertIdentity(op Operation, returning bool, tx ...*sql.Tx) (string, error) {
+	if returning {
+		rows, err := db.rawSelect(op, tx...)
+		if err != nil {
+			return "", err
+		}
+		if len(rows) > 0 {
+			for _, vl := range rows[0] {
+				if id, ok := vl.(*string); ok && id != nil {
+					return *id, nil
+				}
+			}
+		}
+		return "", errors.New("no key returned from insert")
+	}
+	res, err := db.execute(op, tx...)
+	if err != nil {
+		return "", err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(id, 10), nil
+}
+
+// readBack loads the values of the specified fields of a saved model
+// from the database. This is used to retrieve values computed by the database
+// @param m
+// @param flds
+// @param tx
+// @return error
+func (db *DB) readBack(m Modeller, flds []field, tx ...*sql.Tx) error {
+	if len(flds) == 0 {
+		return nil
+	}
+	cols := make([]string, 0, len(flds))
+	for _, f := range flds {
+		cols = append(cols, db.mgr.IdentityString(f.name))
+	}
+	id, _ := db.mgr.MakeValue(*m.GetID())
+	n := GetTableName(m)
+	q := fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s", strings.Join(cols, ", "), db.mgr.IdentityString(n), db.mgr.IdentityString("ID"), id)
+	rows, err := db.rawSelect(Operation{Name: OpReadBack, Table: n, Statement: q}, tx...)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return NoResults("no results")
+	}
+	v := reflect.ValueOf(m).Elem()
+	for _, f := range flds {
+		if vl, ok := rows[0][f.name].(*string); ok && vl != nil {
+			setFieldValue(f.value(v, true), f, *vl)
+		}
+	}
+	return nil
 }
 
 // updateCommand returns the SQL command to update the
-// current model in the database
+// current model in the database, along with the computed fields
+// whose values must be read back. The command is empty if the model
+// has not changed since it was loaded
 // @param m
 // @return string
-func (db *DB) updateCommand(m Modeller) (string, error) {
+// @return []field
+// @return error
+func (db *DB) updateCommand(m Modeller) (string, []field, error) {
 	flds, n, err := db.tableTest(m)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+	if err := db.tenantModel(m, flds); err != nil {
+		return "", nil, err
+	}
+	// Models with a snapshot only write the fields that have changed, and
+	// are not written at all if nothing has
+	changed := changes(m, flds)
+	if changed != nil && len(changed) == 0 {
+		return "", nil, nil
 	}
 	now := time.Now()
 	db.updateLastUpdate(m, now)
-	res := fmt.Sprintf("UPDATE %s SET", n)
 	v := reflect.ValueOf(m)
-	first := true
+	sets := make([]string, 0, len(flds))
+	readBack := make([]field, 0)
 	for _, f := range flds {
-		if f.name != "ID" && f.name != "CreateDate" {
-			if !first {
-				res += ","
-			}
-			first = false
-			var value interface{}
-			if f.allowNull {
-				if v.Elem().FieldByName(f.name).IsNil() {
-					res += fmt.Sprintf(" %s = null", db.mgr.IdentityString(f.name))
-					continue
-				}
-				value = v.Elem().FieldByName(f.name).Elem().Interface()
-			} else {
-				value = v.Elem().FieldByName(f.name).Interface()
-			}
-			if vl, ok := utils.MakeValue(value); ok {
-				res += fmt.Sprintf(" %s = %s", db.mgr.IdentityString(f.name), vl)
-			}
+		if f.computed != "" {
+			readBack = append(readBack, f)
+		}
+		if !f.updatable() {
+			continue
+		}
+		if _, ok := changed[f.name]; changed != nil && f.tracked() && !ok {
+			continue
+		}
+		value, ok := f.get(v.Elem())
+		if !ok {
+			sets = append(sets, fmt.Sprintf("%s = null", db.mgr.IdentityString(f.name)))
+			continue
+		}
+		if vl, ok := db.mgr.MakeValue(value); ok {
+			sets = append(sets, fmt.Sprintf("%s = %s", db.mgr.IdentityString(f.name), vl))
 		}
 	}
-	def := res + fmt.Sprintf(" WHERE %s = '%s'", db.mgr.IdentityString("Id"), *m.GetID())
-	return def, nil
+	id, _ := db.mgr.MakeValue(*m.GetID())
+	def := fmt.Sprintf("UPDATE %s SET %s WHERE %s = %s", db.mgr.IdentityString(n), strings.Join(sets, ", "), db.mgr.IdentityString("ID"), id)
+	// The row must also belong to the tenant, whatever the ID of the model
+	cond, err := db.tenantCondition(flds)
+	if err != nil {
+		return "", nil, err
+	}
+	if cond != "" {
+		def += " AND " + cond
+	}
+	return def, readBack, nil
 }
 
 func (db *DB) updateLastUpdate(m Modeller, date time.Time) {
@@ -872,10 +1113,13 @@ func (db *DB) tableTest(m Modeller) ([]field, string, error) {
 		db.knownTables = append(db.knownTables, n)
 		if !te {
 			for _, s := range sql {
-				if err := db.executeQuery(s); err != nil {
+				if err := db.executeQuery(Operation{Name: OpCreateTable, Table: n, Statement: s}); err != nil {
 					return nil, "", err
 				}
 			}
+			if err := db.fullTextCreate(n, db.tableDef[n]); err != nil {
+				return nil, "", err
+			}
 			if sd := m.StandingData(); sd != nil {
 				for _, data := range sd {
 					db.Save(data)
@@ -902,18 +1146,65 @@ func (db *DB) Save(m Modeller, tx ...*sql.Tx) error {
 			return err
 		}
 	}
+	var err error
+	if db.audited(m) {
+		err = db.saveAudited(m, tx...)
+	} else {
+		err = db.save(m, tx...)
+	}
+	if err != nil {
+		return err
+	}
+	// The caller's transaction may yet be rolled back, so the stored values
+	// are only known once the model is saved outside of one
+	if len(tx) > 0 {
+		if s, ok := m.(snapshotter); ok {
+			s.setSnapshot(nil)
+		}
+		return nil
+	}
+	takeSnapshot(m, db.tableDef[GetTableName(m)])
+	return nil
+}
+
+// save inserts or updates the model
+// @param m
+// @param tx
+// @return error
+func (db *DB) save(m Modeller, tx ...*sql.Tx) error {
 	if m.IsNew() {
-		cmd, err := db.insertCommand(m)
+		cmd, readBack, returning, err := db.insertCommand(m)
 		if err != nil {
 			return err
 		}
-		return db.executeQuery(cmd)
+		op := Operation{Name: OpInsert, Table: GetTableName(m), Statement: cmd}
+		if isAutoIncrement(m) {
+			id, err := db.insertIdentity(op, returning, tx...)
+			if err != nil {
+				return err
+			}
+			db.setID(m, id)
+		} else if err := db.executeQuery(op, tx...); err != nil {
+			return err
+		}
+		return db.readBack(m, readBack, tx...)
 	}
-	updCmd, err := db.updateCommand(m)
+	updCmd, readBack, err := db.updateCommand(m)
+	if err != nil || updCmd == "" {
+		return err
+	}
+	res, err := db.execute(Operation{Name: OpUpdate, Table: GetTableName(m), Statement: updCmd}, tx...)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
 	if err != nil {
 		return err
 	}
-	return db.executeQuery(updCmd, tx...)
+	if err := db.tenantWritten(m, db.tableDef[GetTableName(m)], rows, tx...); err != nil {
+		return err
+	}
+	return db.readBack(m, readBack, tx...)
 }
 
 // Remove removes the passed model from the database
@@ -923,33 +1214,80 @@ func (db *DB) Remove(m Modeller, tx ...*sql.Tx) error {
 	if m.GetID() == nil {
 		return nil
 	}
-	c := &Criteria{
+	flds, _, err := db.tableTest(m)
+	if err != nil {
+		return err
+	}
+	if err := db.tenantModel(m, flds); err != nil {
+		return err
+	}
+	c := Criteria{
 		Where: where.Equal("ID", *m.GetID()),
 	}
-	var s string
-	if db.cfg.Deletable {
-		s = db.massDelete(m, c)
-	} else {
-		s = db.massDisable(m, c)
+	rows, err := db.remove(m, c.Unscoped(), tx...)
+	if err != nil {
+		return err
 	}
-	return db.executeQuery(s, tx...)
+	return db.tenantWritten(m, flds, rows, tx...)
 }
 
-func (db *DB) massDelete(m Modeller, c *Criteria) string {
-	name := GetTableName(m)
-	s := fmt.Sprintf("DELETE FROM %s", db.mgr.IdentityString(name))
-	whereAdded := false
+// remove removes the models matching the criteria, recording the changes if
+// the model is audited. Returns the number of rows affected
+// @param m
+// @param c
+// @param tx
+// @return int64
+// @return error
+func (db *DB) remove(m Modeller, c *Criteria, tx ...*sql.Tx) (int64, error) {
+	if db.audited(m) {
+		return db.removeAudited(m, c, tx...)
+	}
+	rc, err := db.removeCriteria(m, c)
+	if err != nil {
+		return 0, err
+	}
+	res, err := db.execute(Operation{Name: OpDelete, Table: GetTableName(m), Statement: db.removeStatement(m, rc)}, tx...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
 
-	if c != nil && c.Where != "" {
-		s += fmt.Sprintf(" WHERE %s", c.WhereString(db.mgr))
-		whereAdded = true
+// removeCriteria returns the criteria selecting the models to remove, with the
+// default scope and tenant applied
+// @param m
+// @param c
+// @return *Criteria
+// @return error
+func (db *DB) removeCriteria(m Modeller, c *Criteria) (*Criteria, error) {
+	flds, _, err := db.tableTest(m)
+	if err != nil {
+		return nil, err
 	}
-	if whereAdded {
-		s += fmt.Sprintf(" AND %s IS NULL", db.mgr.IdentityString("[DeleteDate]"))
-	} else {
-		s += fmt.Sprintf(" WHERE %s IS NULL", db.mgr.IdentityString("[DeleteDate]"))
+	if c, err = db.scope(m, flds, c); err != nil {
+		return nil, err
 	}
-	return s
+	// Only models that have not already been disabled are removed
+	rc := *c
+	rc.IncDeleted = false
+	return &rc, nil
+}
+
+// removeStatement returns the SQL command that removes the models matching the
+// criteria, which deletes them if the database is deletable and disables them otherwise
+// @param m
+// @param c
+// @return string
+func (db *DB) removeStatement(m Modeller, c *Criteria) string {
+	if db.cfg.Deletable {
+		return db.massDelete(m, c)
+	}
+	return db.massDisable(m, c)
+}
+
+func (db *DB) massDelete(m Modeller, c *Criteria) string {
+	name := GetTableName(m)
+	return fmt.Sprintf("DELETE FROM %s %s", db.mgr.IdentityString(name), strings.TrimSpace(c.WhereString(db.mgr)))
 }
 
 func (db *DB) massDisable(m Modeller, c *Criteria) string {
@@ -957,21 +1295,7 @@ func (db *DB) massDisable(m Modeller, c *Criteria) string {
 	deleteDate := db.mgr.IdentityString("DeleteDate")
 	name := GetTableName(m)
 	s := fmt.Sprintf("UPDATE %s SET %s = '%v'", db.mgr.IdentityString(name), deleteDate, utils.TimeToSQL(tm))
-	log.Println(c.WhereString(db.mgr))
-	whereAdded := false
-	if c != nil && c.Where != "" {
-		trimmed := strings.TrimSpace(c.WhereString(db.mgr))
-		if trimmed != "" {
-			s += fmt.Sprintf(" %s", trimmed)
-			whereAdded = true
-		}
-	}
-	if whereAdded {
-		s += fmt.Sprintf(" AND %s IS NULL", deleteDate)
-	} else {
-		s += fmt.Sprintf(" WHERE %s IS NULL", deleteDate)
-	}
-	return s
+	return s + " " + strings.TrimSpace(c.WhereString(db.mgr))
 }
 
 // RemoveMany removes all models of the specified type that match the criteria
@@ -983,17 +1307,16 @@ func (db *DB) RemoveMany(m Modeller, c *Criteria, tx ...*sql.Tx) (int, error) {
 	if !db.tableExists(t) {
 		return 0, nil
 	}
-	r := db.Count(m, c)
+	// Count on the primary, which the models are removed from
+	c = db.defaultScope(m, c)
+	r, err := db.UsePrimary().count(m, c, tx...)
+	if err != nil {
+		return 0, err
+	}
 	if r == 0 {
 		return 0, nil
 	}
-	s := ""
-	if db.cfg.Deletable {
-		s = db.massDelete(m, c)
-	} else {
-		s = db.massDisable(m, c)
-	}
-	err := db.executeQuery(s, tx...)
+	_, err = db.remove(m, c, tx...)
 	return r, err
 }
 
@@ -1013,6 +1336,12 @@ func (db *DB) tableDefinition(m Modeller) ([]string, bool) {
 		nm = reflect.New(t).Elem().Interface()
 	}
 	flds := getDefs(nm, true)
+	if isAutoIncrement(m) {
+		// The key is an integer generated by the database, and is indexed as the primary key
+		flds[0].fType = tLong
+		flds[0].autoIncrement = true
+		flds[0].key = false
+	}
 
 	db.tableDef[n] = flds
 	if len(flds) == 0 {
@@ -1024,18 +1353,9 @@ func (db *DB) tableDefinition(m Modeller) ([]string, bool) {
 		if fldsStr != "" {
 			fldsStr += ", "
 		}
-		fldsStr += fmt.Sprintf("%s %s", db.mgr.IdentityString(f.name), pgFieldNames[f.fType])
-		if f.fType != tUUID && f.fType != tChar && f.size.Size > 0 {
-			fldsStr += fmt.Sprintf("(%d)", f.size.Size)
-		}
-		if f.fType == tString && f.size.Size == 0 {
-			fldsStr += "(256)"
-		}
-		if f.unsigned {
-			fldsStr += " UNSIGNED"
-		}
-		if !f.allowNull {
-			fldsStr += " NOT NULL"
+		fldsStr += fmt.Sprintf("%s %s", db.mgr.IdentityString(f.name), db.mgr.ColumnType(f.column()))
+		if def, ok := f.defaultString(db.mgr); ok {
+			fldsStr += fmt.Sprintf(" DEFAULT %s", def)
 		}
 		if f.key {
 			keys = append(keys, f.name)
@@ -1049,11 +1369,35 @@ func (db *DB) tableDefinition(m Modeller) ([]string, bool) {
 	return sql, true
 }
 
+// fullTextCreate creates the full-text objects of the fulltext fields of the
+// table. The statements are executed outside of a transaction, as SQL Server
+// does not allow full-text catalogs and indexes to be created within one
+// @param n
+// @param flds
+// @return error
+func (db *DB) fullTextCreate(n string, flds []field) error {
+	ft := make([]string, 0)
+	for _, f := range flds {
+		if f.fulltext {
+			ft = append(ft, f.name)
+		}
+	}
+	if len(ft) == 0 {
+		return nil
+	}
+	for _, s := range db.mgr.FullTextCreate(n, ft) {
+		if _, err := db.exec(nil, Operation{Name: OpCreateTable, Table: n, Statement: s}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (db *DB) Refresh(m Modeller) error {
 	if m.GetID() == nil {
 		return errors.New("no id")
 	}
-	m, err := db.First(m, where.Equal("ID", m.GetID()))
+	m, err := db.First(m, Criteria{Where: where.Equal("ID", m.GetID())}.Unscoped())
 	return err
 }
 
@@ -1081,11 +1425,28 @@ func First[T Modeller](db *DB, criteria ...interface{}) (*T, error) {
 	return r[0], err
 }
 
+// Range returns an iterator over the models that match the criteria.
+// Iteration stops silently on any error; use RangeErr to receive errors
 func Range[T Modeller](db *DB, criteria ...interface{}) iter.Seq[*T] {
-	m := new(T)
 	return func(yield func(*T) bool) {
-		for mdl := range db.Range(*m, criteria...) {
-			if !yield(utils.Ptr(mdl.(T))) {
+		for mdl, err := range RangeErr[T](db, criteria...) {
+			if err != nil || !yield(mdl) {
+				return
+			}
+		}
+	}
+}
+
+// RangeErr returns an iterator over the models that match the criteria,
+// yielding any failure as an error with a nil model, after which iteration ends
+func RangeErr[T Modeller](db *DB, criteria ...interface{}) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		for mdl, err := range db.RangeErr(*new(T), criteria...) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(utils.Ptr(mdl.(T)), nil) {
 				return
 			}
 		}
@@ -0,0 +1,152 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+
+// Package mud provides tracking of the changes made to loaded models.
+package mud
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/markoxley/mud/utils"
+)
+
+// Change holds the original and current values of a changed field.
+// Values are nil for null fields.
+type Change struct {
+	Old interface{}
+	New interface{}
+}
+
+// snapshotValue is the value of a field along with its SQL literal,
+// which is used to compare values
+type snapshotValue struct {
+	value   interface{}
+	literal string
+}
+
+// snapshot holds the values of the tracked fields of a model
+type snapshot map[string]snapshotValue
+
+// snapshotter is implemented by models that embed Model
+type snapshotter interface {
+	getSnapshot() snapshot
+	setSnapshot(s snapshot)
+}
+
+// getSnapshot returns the values of the fields when the model was loaded or last saved
+func (m *Model) getSnapshot() snapshot {
+	if m.snapshot == nil {
+		return nil
+	}
+	return *m.snapshot
+}
+
+// setSnapshot replaces the snapshot of the model
+func (m *Model) setSnapshot(s snapshot) {
+	if s == nil {
+		m.snapshot = nil
+		return
+	}
+	m.snapshot = &s
+}
+
+// tracked reports whether changes to the field are tracked. The time of the
+// last update changes on every save, and is not tracked
+func (f field) tracked() bool {
+	return f.updatable() && f.name != "LastUpdate"
+}
+
+// snapshotOf returns the current values of the tracked fields of the model
+// @param v
+// @param flds
+// @return snapshot
+func snapshotOf(v reflect.Value, flds []field) snapshot {
+	res := make(snapshot, len(flds))
+	for _, f := range flds {
+		if !f.tracked() {
+			continue
+		}
+		vl, ok := f.get(v)
+		if !ok {
+			res[f.name] = snapshotValue{literal: "null"}
+			continue
+		}
+		lit, ok := utils.MakeValue(vl)
+		if !ok {
+			lit = fmt.Sprint(vl)
+		}
+		res[f.name] = snapshotValue{value: vl, literal: lit}
+	}
+	return res
+}
+
+// takeSnapshot records the current values of the fields of the model, so that
+// later saves only write the fields that change
+// @param m
+// @param flds
+func takeSnapshot(m Modeller, flds []field) {
+	s, ok := m.(snapshotter)
+	if !ok {
+		return
+	}
+	s.setSnapshot(snapshotOf(reflect.ValueOf(m).Elem(), flds))
+}
+
+// updateSnapshot records the current values of the specified fields in the
+// snapshot of the model, leaving the other fields as they were. Models without
+// a snapshot are left without one
+// @param m
+// @param flds
+func updateSnapshot(m Modeller, flds []field) {
+	s, ok := m.(snapshotter)
+	if !ok || s.getSnapshot() == nil {
+		return
+	}
+	res := make(snapshot, len(s.getSnapshot()))
+	for n, vl := range s.getSnapshot() {
+		res[n] = vl
+	}
+	for n, vl := range snapshotOf(reflect.ValueOf(m).Elem(), flds) {
+		res[n] = vl
+	}
+	s.setSnapshot(res)
+}
+
+// changes returns the fields whose values differ from the snapshot.
+// Returns nil if the model has no snapshot
+// @param m
+// @param flds
+// @return map[string]Change
+func changes(m Modeller, flds []field) map[string]Change {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Pointer {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		v = p
+	}
+	s, ok := v.Interface().(snapshotter)
+	if !ok || s.getSnapshot() == nil {
+		return nil
+	}
+	old := s.getSnapshot()
+	res := make(map[string]Change)
+	for n, cur := range snapshotOf(v.Elem(), flds) {
+		if o, ok := old[n]; !ok || o.literal != cur.literal {
+			res[n] = Change{Old: o.value, New: cur.value}
+		}
+	}
+	return res
+}
+
+// Changes returns the fields of the model that have changed since it was
+// loaded or last saved, keyed by field name. Returns nil for models that
+// have not been loaded or saved, as all of their fields are written.
+func Changes(m Modeller) map[string]Change {
+	t := reflect.TypeOf(m)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return changes(m, getDefs(reflect.New(t).Elem().Interface(), true))
+}
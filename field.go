@@ -5,9 +5,18 @@
 // with support for SQLite, MySQL, and SQL Server databases.
 package mud
 
-// fieldType represents the type of a database field.
+import (
+	"reflect"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/markoxley/mud/utils"
+)
+
+// FieldType represents the type of a database field.
 // It maps to various database-specific types and is used for type conversion.
-type fieldType int
+type FieldType int
 
 // fieldName represents the name of a database field.
 type fieldName string
@@ -16,7 +25,7 @@ type fieldName string
 // These correspond to common database data types and are used for type conversion.
 const (
 	// tInt represents an integer field type
-	tInt fieldType = iota
+	tInt FieldType = iota
 	// tLong represents a long integer field type
 	tLong
 	// tBool represents a boolean field type
@@ -52,10 +61,10 @@ const (
 	sUUID     = "uuid"
 )
 
-// fieldNames maps string representations of field types to their corresponding fieldType constants.
+// fieldNames maps string representations of field types to their corresponding FieldType constants.
 // This is used for type conversion and validation.
 var (
-	fieldNames = map[string]fieldType{
+	fieldNames = map[string]FieldType{
 		sInt:      tInt,
 		sLong:     tLong,
 		sBool:     tBool,
@@ -69,22 +78,30 @@ var (
 	}
 )
 
-// pgFieldNames maps fieldType constants to their PostgreSQL-specific database types.
-// This is used for generating correct database schema definitions for PostgreSQL.
-var (
-	pgFieldNames = map[fieldType]string{
-		tInt:      "INT",
-		tLong:     "BIGINT",
-		tBool:     "SMALLINT",
-		tDecimal:  "DECIMAL",
-		tFloat:    "REAL",
-		tDouble:   "DOUBLE",
-		tDateTime: "DATETIME",
-		tChar:     "VARCHAR(1)",
-		tString:   "VARCHAR",
-		tUUID:     "VARCHAR(36)",
+// ParseFieldType returns the FieldType named by a mud type tag value,
+// such as "int", "string" or "time".
+// Returns false if the name is not a known field type.
+func ParseFieldType(name string) (FieldType, bool) {
+	if name == "time" {
+		name = sDateTime
 	}
-)
+	t, ok := fieldNames[name]
+	return t, ok
+}
+
+// String returns the name of the field type, as used in the mud type tag.
+// Date/time fields are reported as "time" rather than the internal "struct" key.
+func (t FieldType) String() string {
+	if t == tDateTime {
+		return "time"
+	}
+	for k, v := range fieldNames {
+		if v == t {
+			return k
+		}
+	}
+	return "unknown"
+}
 
 // field represents a database field definition.
 // It contains all the necessary information to define and work with a database column.
@@ -92,7 +109,7 @@ type field struct {
 	// name is the name of the field in the database
 	name string
 	// fType is the type of the field (int, string, etc.)
-	fType fieldType
+	fType FieldType
 	// size contains the size and decimal places for numeric types
 	size FieldSize
 	// identity indicates if this field is an auto-incrementing primary key
@@ -103,6 +120,24 @@ type field struct {
 	unsigned bool
 	// allowNull indicates if NULL values are allowed for this field
 	allowNull bool
+	// defValue is the literal default value of the column, if any
+	defValue *string
+	// dbDefault is an expression the database uses to compute the default value
+	dbDefault string
+	// readOnly indicates the field is never written to the database
+	readOnly bool
+	// insertOnly indicates the field is written on insert but never updated
+	insertOnly bool
+	// computed is the expression of a generated column
+	computed string
+	// path is the index path of the field within the model struct
+	path []int
+	// autoIncrement indicates the value is generated by the database
+	autoIncrement bool
+	// tenant indicates the field holds the tenant that owns the row
+	tenant bool
+	// fulltext indicates the field is searched with where.Match
+	fulltext bool
 }
 
 // newField creates a new field definition with the specified properties.
@@ -121,7 +156,7 @@ type field struct {
 // Returns:
 //
 //	A new field definition
-func newField(nm string, tp fieldType, sz, dec int, id, ky, us bool, nl bool) field {
+func newField(nm string, tp FieldType, sz, dec int, id, ky, us bool, nl bool) field {
 	return field{
 		name:      nm,
 		fType:     tp,
@@ -132,3 +167,200 @@ func newField(nm string, tp fieldType, sz, dec int, id, ky, us bool, nl bool) fi
 		allowNull: nl,
 	}
 }
+
+// column returns the exported description of the field that is passed to
+// the Manager when generating DDL.
+func (f field) column() Column {
+	return Column{
+		Name:          f.name,
+		Type:          f.fType,
+		Size:          f.size,
+		Unsigned:      f.unsigned,
+		AllowNull:     f.allowNull,
+		Computed:      f.computed,
+		AutoIncrement: f.autoIncrement,
+	}
+}
+
+// value returns the struct field within the model struct v.
+// Nil nested struct pointers are allocated when alloc is set,
+// otherwise an invalid value is returned for them.
+func (f field) value(v reflect.Value, alloc bool) reflect.Value {
+	for i, idx := range f.path {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(idx)
+	}
+	return v
+}
+
+// get returns the value of the field within the model struct v,
+// dereferencing pointers. Returns false if the value is NULL
+func (f field) get(v reflect.Value) (interface{}, bool) {
+	fv := f.value(v, false)
+	if !fv.IsValid() {
+		return nil, false
+	}
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return nil, false
+		}
+		fv = fv.Elem()
+	}
+	return fv.Interface(), true
+}
+
+// unset reports whether no value has been given for the field, so that a
+// default applies. A pointer field is only unset when nil, so that it can be
+// set to the zero value explicitly
+func (f field) unset(v reflect.Value) bool {
+	fv := f.value(v, false)
+	if !fv.IsValid() {
+		return true
+	}
+	if fv.Kind() == reflect.Pointer {
+		return fv.IsNil()
+	}
+	return fv.IsZero()
+}
+
+// insertable reports whether the field is written when a model is inserted
+func (f field) insertable() bool {
+	return !f.readOnly && f.computed == ""
+}
+
+// updatable reports whether the field is written when a model is updated
+func (f field) updatable() bool {
+	return f.insertable() && !f.insertOnly && f.name != "ID" && f.name != "CreateDate"
+}
+
+// Column describes a model field for the purpose of generating a column definition.
+// It is passed to Manager.ColumnType so that each database can map the field to its own types.
+type Column struct {
+	// Name is the name of the column
+	Name string
+	// Type is the mud field type of the column
+	Type FieldType
+	// Size contains the length, or precision and scale, of the column
+	Size FieldSize
+	// Unsigned indicates that the Go type of the field is unsigned
+	Unsigned bool
+	// AllowNull indicates that the column may contain NULL values
+	AllowNull bool
+	// Computed is the expression of a generated column, or empty for a stored column
+	Computed string
+	// AutoIncrement indicates the column is an auto-incrementing primary key
+	AutoIncrement bool
+}
+
+// hasDefault reports whether the database supplies a value for the field
+// when it is omitted from an insert
+func (f field) hasDefault() bool {
+	return f.defValue != nil || f.dbDefault != ""
+}
+
+// defaultString returns the DEFAULT expression for the field in SQL format.
+// Literal defaults are converted to the Go type of the field so that the
+// manager can render them correctly.
+// Returns false if the field has no default
+func (f field) defaultString(mgr Manager) (string, bool) {
+	if f.computed != "" {
+		return "", false
+	}
+	if f.dbDefault != "" {
+		return f.dbDefault, true
+	}
+	if f.defValue == nil {
+		return "", false
+	}
+	dv := *f.defValue
+	var value interface{} = dv
+	switch f.fType {
+	case tInt, tLong:
+		if f.unsigned {
+			if v, err := strconv.ParseUint(dv, 10, 64); err == nil {
+				value = v
+			}
+		} else if v, err := strconv.ParseInt(dv, 10, 64); err == nil {
+			value = v
+		}
+	case tBool:
+		if v, err := strconv.ParseBool(dv); err == nil {
+			value = v
+		}
+	case tDecimal, tFloat, tDouble:
+		if v, err := strconv.ParseFloat(dv, 64); err == nil {
+			value = v
+		}
+	case tDateTime:
+		if v, ok := utils.SQLToTime(dv); ok {
+			value = *v
+		}
+	}
+	return mgr.MakeValue(value)
+}
+
+// setFieldValue converts a value read from the database and assigns it to
+// the struct field fv. Pointer fields are allocated as required.
+// Returns false if the value could not be converted
+func setFieldValue(fv reflect.Value, fld field, s string) bool {
+	if fv.Kind() == reflect.Pointer {
+		nv := reflect.New(fv.Type().Elem())
+		if !setFieldValue(nv.Elem(), fld, s) {
+			return false
+		}
+		fv.Set(nv)
+		return true
+	}
+	switch fv.Kind() {
+	case reflect.Bool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return false
+		}
+		fv.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return false
+		}
+		fv.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return false
+		}
+		fv.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return false
+		}
+		fv.SetFloat(v)
+	case reflect.String:
+		if fld.fType == tChar && s != "" {
+			_, sz := utf8.DecodeRuneInString(s)
+			s = s[:sz]
+		}
+		fv.SetString(s)
+	case reflect.Struct:
+		if fv.Type() != reflect.TypeOf(time.Time{}) {
+			return false
+		}
+		v, ok := utils.SQLToTime(s)
+		if !ok {
+			return false
+		}
+		fv.Set(reflect.ValueOf(*v))
+	default:
+		return false
+	}
+	return true
+}
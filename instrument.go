@@ -0,0 +1,330 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+
+// Package mud provides tracing and metrics hooks for database operations.
+package mud
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Operation names reported to instrumenters
+const (
+	OpSelect      = "select"
+	OpCount       = "count"
+	OpInsert      = "insert"
+	OpUpdate      = "update"
+	OpDelete      = "delete"
+	OpReadBack    = "read_back"
+	OpCreateTable = "create_table"
+	OpTableExists = "table_exists"
+	OpRaw         = "raw"
+)
+
+// Operation describes a statement executed on behalf of a DB method.
+type Operation struct {
+	// Name is the kind of operation, such as OpSelect or OpInsert
+	Name string
+	// Table is the table of the model the operation applies to.
+	// It is empty for raw statements
+	Table string
+	// Statement is the SQL executed
+	Statement string
+}
+
+// ErrorClass groups database errors into broad categories for metrics.
+type ErrorClass string
+
+// Error classes returned by ClassifyError
+const (
+	ClassNone       ErrorClass = ""
+	ClassNoRows     ErrorClass = "no_rows"
+	ClassCanceled   ErrorClass = "canceled"
+	ClassTimeout    ErrorClass = "timeout"
+	ClassConnection ErrorClass = "connection"
+	ClassConstraint ErrorClass = "constraint"
+	ClassSyntax     ErrorClass = "syntax" // Invalid statements, including unknown tables and columns
+	ClassOther      ErrorClass = "other"
+)
+
+// OperationResult describes the outcome of an operation.
+type OperationResult struct {
+	// Duration is the time taken to execute the statement
+	Duration time.Duration
+	// Rows is the number of rows affected, or -1 if it is not known
+	Rows int64
+	// Err is the error returned by the database, if any
+	Err error
+	// Class is the class of Err
+	Class ErrorClass
+}
+
+// Instrumenter defines the interface for tracing and metrics hooks.
+// Start is called before each statement is executed, and the context it returns
+// is used to execute the statement and passed to End. End is called once the
+// statement has completed.
+type Instrumenter interface {
+	Start(ctx context.Context, op Operation) context.Context
+	End(ctx context.Context, op Operation, res OperationResult)
+}
+
+// SetInstrumenters sets the instrumenters notified of every database operation.
+// Instrumenters are started in the order given and ended in reverse order.
+// Calling it with no instrumenters disables instrumentation.
+// @param i
+func (db *DB) SetInstrumenters(i ...Instrumenter) {
+	db.instrumenters = i
+}
+
+// WithContext returns a handle to the database whose operations use ctx.
+// The handle shares the connection pool, logger and instrumenters of db.
+// Cancelling ctx aborts the statements executed through the handle.
+// @param ctx
+// @return *DB
+func (db *DB) WithContext(ctx context.Context) *DB {
+	c := *db
+	c.ctx = ctx
+	c.derived = true
+	return &c
+}
+
+// context returns the context used for database operations
+func (db *DB) context() context.Context {
+	if db.ctx == nil {
+		return context.Background()
+	}
+	return db.ctx
+}
+
+// ClassifyError returns the class of an error returned by the database.
+// Driver errors are classified by their message, as each driver reports
+// constraint and syntax errors differently.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ClassNone
+	}
+	var nr ErrNoResults
+	if errors.Is(err, sql.ErrNoRows) || errors.As(err, &nr) {
+		return ClassNoRows
+	}
+	if errors.Is(err, context.Canceled) {
+		return ClassCanceled
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ClassTimeout
+	}
+	var ne net.Error
+	if errors.As(err, &ne) {
+		if ne.Timeout() {
+			return ClassTimeout
+		}
+		return ClassConnection
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return ClassConnection
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"constraint", "duplicate", "unique"} {
+		if strings.Contains(msg, s) {
+			return ClassConstraint
+		}
+	}
+	for _, s := range []string{"syntax", "incomplete input", "no such", "invalid object name", "invalid column name", "unknown column", "doesn't exist"} {
+		if strings.Contains(msg, s) {
+			return ClassSyntax
+		}
+	}
+	return ClassOther
+}
+
+// histogramBounds are the upper bounds of the latency histogram buckets
+var histogramBounds = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// HistogramBucket holds the number of operations that took at most UpperBound.
+// The last bucket of a histogram has an UpperBound of zero and counts all operations.
+type HistogramBucket struct {
+	UpperBound time.Duration
+	Count      int64
+}
+
+// OperationStats holds the metrics of an operation on a table.
+type OperationStats struct {
+	Name  string
+	Table string
+	// Count is the number of operations executed
+	Count int64
+	// Errors is the number of operations that failed, by error class
+	Errors map[ErrorClass]int64
+	// Total is the total time spent executing the operations
+	Total time.Duration
+	// Max is the longest time taken by a single operation
+	Max time.Duration
+	// Buckets is the cumulative latency histogram of the operations
+	Buckets []HistogramBucket
+}
+
+// Mean returns the average time taken by the operations.
+func (s OperationStats) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// ErrorCount returns the number of operations that failed.
+func (s OperationStats) ErrorCount() int64 {
+	var n int64
+	for _, c := range s.Errors {
+		n += c
+	}
+	return n
+}
+
+// Stats is a snapshot of the connection pool and operation metrics of a DB.
+type Stats struct {
+	// Pool holds the statistics of the connection pool
+	Pool sql.DBStats
+	// Replicas holds the health and connection pool statistics of each read replica
+	Replicas []ReplicaStats
+	// Operations holds the metrics recorded by the instrumenters, ordered by table and operation
+	Operations []OperationStats
+}
+
+// ReplicaStats is a snapshot of a read replica
+type ReplicaStats struct {
+	Name    string
+	Healthy bool
+	Pool    sql.DBStats
+}
+
+// metricsSource is implemented by instrumenters whose metrics are included in Stats
+type metricsSource interface {
+	Snapshot() []OperationStats
+}
+
+// Stats returns the statistics of the connection pool, along with the metrics
+// of any instrumenter that records them, such as Metrics.
+// @return Stats
+func (db *DB) Stats() Stats {
+	res := Stats{Operations: make([]OperationStats, 0)}
+	if db.db != nil {
+		res.Pool = db.db.Stats()
+	}
+	for _, r := range db.replicas {
+		res.Replicas = append(res.Replicas, ReplicaStats{Name: r.Name(), Healthy: r.Healthy(), Pool: r.Stats()})
+	}
+	for _, i := range db.instrumenters {
+		if ms, ok := i.(metricsSource); ok {
+			res.Operations = append(res.Operations, ms.Snapshot()...)
+		}
+	}
+	return res
+}
+
+// metricsKey identifies the operations recorded together
+type metricsKey struct {
+	name  string
+	table string
+}
+
+// Metrics is an Instrumenter that keeps in-process counters and latency
+// histograms for each operation and table.
+type Metrics struct {
+	mu  sync.Mutex
+	ops map[metricsKey]*OperationStats
+}
+
+// NewMetrics creates an empty Metrics instrumenter.
+func NewMetrics() *Metrics {
+	return &Metrics{ops: make(map[metricsKey]*OperationStats)}
+}
+
+// Start implements Instrumenter. Metrics are recorded when the operation ends.
+func (m *Metrics) Start(ctx context.Context, op Operation) context.Context {
+	return ctx
+}
+
+// End records the outcome of the operation.
+func (m *Metrics) End(ctx context.Context, op Operation, res OperationResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := metricsKey{name: op.Name, table: op.Table}
+	s, ok := m.ops[k]
+	if !ok {
+		s = &OperationStats{
+			Name:    op.Name,
+			Table:   op.Table,
+			Errors:  make(map[ErrorClass]int64),
+			Buckets: make([]HistogramBucket, len(histogramBounds)+1),
+		}
+		for i, b := range histogramBounds {
+			s.Buckets[i].UpperBound = b
+		}
+		m.ops[k] = s
+	}
+	s.Count++
+	s.Total += res.Duration
+	if res.Duration > s.Max {
+		s.Max = res.Duration
+	}
+	if res.Class != ClassNone {
+		s.Errors[res.Class]++
+	}
+	for i := range s.Buckets {
+		if s.Buckets[i].UpperBound == 0 || res.Duration <= s.Buckets[i].UpperBound {
+			s.Buckets[i].Count++
+		}
+	}
+}
+
+// Snapshot returns a copy of the metrics recorded, ordered by table and operation.
+func (m *Metrics) Snapshot() []OperationStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	res := make([]OperationStats, 0, len(m.ops))
+	for _, s := range m.ops {
+		c := *s
+		c.Errors = make(map[ErrorClass]int64, len(s.Errors))
+		for k, v := range s.Errors {
+			c.Errors[k] = v
+		}
+		c.Buckets = append([]HistogramBucket(nil), s.Buckets...)
+		res = append(res, c)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Table != res[j].Table {
+			return res[i].Table < res[j].Table
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// Reset clears the metrics recorded.
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ops = make(map[metricsKey]*OperationStats)
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+// Package mud provides primary key generation strategies for models.
+package mud
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	guuid "github.com/google/uuid"
+	uuid "github.com/satori/go.uuid"
+)
+
+// IDGenerator defines the interface for primary key generators.
+// Implement this interface to supply keys from a custom source.
+type IDGenerator interface {
+	// NewID returns a new primary key value.
+	NewID() (string, error)
+}
+
+// KeyGenerator may be implemented by a model to choose how its primary key
+// is generated. Models that do not implement it use UUIDv4 keys.
+type KeyGenerator interface {
+	// KeyGenerator returns the generator used for new records of the model.
+	KeyGenerator() IDGenerator
+}
+
+// Key generation strategies available to models.
+var (
+	// UUIDv4 generates random version 4 UUIDs. This is the default strategy.
+	UUIDv4 IDGenerator = uuidV4Generator{}
+	// UUIDv7 generates time ordered version 7 UUIDs.
+	UUIDv7 IDGenerator = uuidV7Generator{}
+	// SequentialGUID generates GUIDs that increase monotonically within the process.
+	// Keys are prefixed with the time in milliseconds and a sequence number so that
+	// they sort in creation order, keeping clustered indexes on the key compact.
+	SequentialGUID IDGenerator = &sequentialGenerator{}
+	// ULID generates lexicographically sortable identifiers.
+	ULID IDGenerator = ulidGenerator{}
+	// AutoIncrement stores the key in an auto-incrementing integer column.
+	// The key is generated by the database and read back after insert.
+	AutoIncrement IDGenerator = autoIncrementGenerator{}
+)
+
+// uuidV4Generator generates random version 4 UUIDs
+type uuidV4Generator struct{}
+
+// NewID returns a new version 4 UUID.
+func (uuidV4Generator) NewID() (string, error) {
+	return uuid.NewV4().String(), nil
+}
+
+// uuidV7Generator generates time ordered version 7 UUIDs
+type uuidV7Generator struct{}
+
+// NewID returns a new version 7 UUID.
+func (uuidV7Generator) NewID() (string, error) {
+	id, err := guuid.NewV7()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+// sequentialGenerator generates GUIDs made up of a 48 bit millisecond
+// timestamp, a 16 bit sequence number and 64 random bits
+type sequentialGenerator struct {
+	mu   sync.Mutex
+	last int64
+	seq  uint16
+}
+
+// NewID returns a new sequential GUID.
+func (g *sequentialGenerator) NewID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[8:]); err != nil {
+		return "", err
+	}
+
+	g.mu.Lock()
+	ms := time.Now().UnixMilli()
+	if ms <= g.last {
+		// Keep increasing within the same millisecond, or if the clock goes back
+		ms = g.last
+		g.seq++
+		if g.seq == 0 {
+			ms++
+		}
+	} else {
+		g.seq = 0
+	}
+	g.last = ms
+	seq := g.seq
+	g.mu.Unlock()
+
+	binary.BigEndian.PutUint64(b[:8], uint64(ms)<<16|uint64(seq))
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// ulidAlphabet is the Crockford base32 alphabet used to encode ULIDs
+const ulidAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidGenerator generates ULIDs
+type ulidGenerator struct{}
+
+// NewID returns a new ULID.
+func (ulidGenerator) NewID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "", err
+	}
+	ms := uint64(time.Now().UnixMilli())
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+
+	// Encode the 128 bits as 26 characters of 5 bits, least significant first
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	var out [26]byte
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = ulidAlphabet[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:]), nil
+}
+
+// autoIncrementGenerator marks a model as using a database generated key
+type autoIncrementGenerator struct{}
+
+// NewID returns an empty key, as the key is generated by the database.
+func (autoIncrementGenerator) NewID() (string, error) {
+	return "", nil
+}
+
+// keyGenerator returns the key generator for the model.
+// Both the model and a pointer to it are checked for the KeyGenerator interface
+// so that the strategy is the same whether the model is passed by value or pointer.
+func keyGenerator(m Modeller) IDGenerator {
+	if k, ok := m.(KeyGenerator); ok {
+		if g := k.KeyGenerator(); g != nil {
+			return g
+		}
+	}
+	t := reflect.TypeOf(m)
+	if t.Kind() != reflect.Pointer {
+		if k, ok := reflect.New(t).Interface().(KeyGenerator); ok {
+			if g := k.KeyGenerator(); g != nil {
+				return g
+			}
+		}
+	}
+	return UUIDv4
+}
+
+// isAutoIncrement returns true if the model uses a database generated key
+func isAutoIncrement(m Modeller) bool {
+	_, ok := keyGenerator(m).(autoIncrementGenerator)
+	return ok
+}
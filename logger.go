@@ -0,0 +1,133 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+
+// Package mud provides query logging for database operations.
+package mud
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// QueryEvent describes a statement executed on the database.
+type QueryEvent struct {
+	// Statement is the SQL that was executed
+	Statement string
+	// Args holds the bind arguments of the statement, if any
+	Args []interface{}
+	// Duration is the time taken to execute the statement
+	Duration time.Duration
+	// Rows is the number of rows affected, or -1 if it is not known
+	Rows int64
+	// Err is the error returned by the database, if any
+	Err error
+}
+
+// Logger defines the interface for receiving the statements executed by a DB.
+// LogQuery is called once for every statement, after it has been executed.
+type Logger interface {
+	LogQuery(ctx context.Context, e QueryEvent)
+}
+
+// LoggerFunc allows a function to be used as a Logger.
+type LoggerFunc func(ctx context.Context, e QueryEvent)
+
+// LogQuery calls the function with the event.
+func (f LoggerFunc) LogQuery(ctx context.Context, e QueryEvent) {
+	f(ctx, e)
+}
+
+// SetLogger sets the logger that receives every statement executed on the database.
+// Passing nil disables logging.
+// @param l
+func (db *DB) SetLogger(l Logger) {
+	db.logger = l
+}
+
+// SlogLogger is a Logger that writes statements to a log/slog Logger.
+// Statements are logged at Level, statements that take at least SlowThreshold
+// at warn, and statements that fail at error.
+type SlogLogger struct {
+	// Logger receives the log records. When nil, slog.Default() is used
+	Logger *slog.Logger
+	// Level is the level of statements that are neither slow nor failed
+	Level slog.Level
+	// SlowThreshold is the duration from which a statement is logged as slow
+	// When zero, statements are never logged as slow
+	SlowThreshold time.Duration
+	// Redact replaces the literal values in statements and the bind arguments
+	// so that sensitive data does not reach the logs
+	Redact bool
+}
+
+// NewSlogLogger creates a SlogLogger that logs statements to l at debug level,
+// and statements taking at least slowThreshold at warn level.
+func NewSlogLogger(l *slog.Logger, slowThreshold time.Duration) *SlogLogger {
+	return &SlogLogger{
+		Logger:        l,
+		Level:         slog.LevelDebug,
+		SlowThreshold: slowThreshold,
+	}
+}
+
+// literalRegex matches the string and numeric literals rendered into statements.
+// Digits within identifiers, such as t1, are not preceded by a word boundary
+var literalRegex = regexp.MustCompile(`N?'(?:[^']|'')*'|\b\d[\w.]*`)
+
+// LogQuery writes the event to the slog Logger.
+func (s *SlogLogger) LogQuery(ctx context.Context, e QueryEvent) {
+	l := s.Logger
+	if l == nil {
+		l = slog.Default()
+	}
+
+	level := s.Level
+	msg := "query"
+	switch {
+	case e.Err != nil:
+		level = slog.LevelError
+		msg = "query failed"
+	case s.SlowThreshold > 0 && e.Duration >= s.SlowThreshold:
+		level = slog.LevelWarn
+		msg = "slow query"
+	}
+	if !l.Enabled(ctx, level) {
+		return
+	}
+
+	stmt := e.Statement
+	args := e.Args
+	if s.Redact {
+		stmt = literalRegex.ReplaceAllStringFunc(stmt, func(l string) string {
+			if strings.HasSuffix(l, "'") {
+				return "'?'"
+			}
+			return "?"
+		})
+		if len(args) > 0 {
+			args = make([]interface{}, len(e.Args))
+			for i := range args {
+				args[i] = "?"
+			}
+		}
+	}
+
+	attrs := []slog.Attr{
+		slog.String("statement", stmt),
+		slog.Duration("duration", e.Duration),
+	}
+	if len(args) > 0 {
+		attrs = append(attrs, slog.Any("args", args))
+	}
+	if e.Rows >= 0 {
+		attrs = append(attrs, slog.Int64("rows", e.Rows))
+	}
+	if e.Err != nil {
+		attrs = append(attrs, slog.Any("error", e.Err))
+	}
+	l.LogAttrs(ctx, level, msg, attrs...)
+}
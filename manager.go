@@ -36,7 +36,8 @@ type Manager interface {
 	// TableExistsQuery generates a query to check if a table exists
 	TableExistsQuery(name string) string
 
-	// Operators returns a list of database-specific operator formats
+	// Operators returns a list of database-specific operator formats.
+	// Formats may refer to the table being queried as {table}
 	Operators() []string
 
 	// TableCreate returns the database-specific table creation template
@@ -44,6 +45,27 @@ type Manager interface {
 
 	// IndexCreate returns the database-specific index creation template
 	IndexCreate() string
+
+	// FullTextCreate returns the statements that create the full-text index
+	// of the fields of a table, which where.Match searches. The statements are
+	// executed outside of a transaction
+	FullTextCreate(table string, fields []string) []string
+
+	// RelevanceOrder returns the ORDER BY term that sorts the rows of a table
+	// by the full-text relevance of the field to the query, most relevant first
+	RelevanceOrder(table string, field string, query string) string
+
+	// ColumnType returns the database-specific column definition for a field,
+	// including its size, sign handling and nullability
+	ColumnType(c Column) string
+
+	// MakeValue converts a Go value into a database-specific SQL literal
+	MakeValue(value interface{}) (string, bool)
+
+	// InsertReturning generates an INSERT statement that returns the value of the
+	// generated key column. Returns false if the statement cannot return the key,
+	// in which case the driver's LastInsertId is used
+	InsertReturning(table string, fields string, values string, key string) (string, bool)
 }
 
 // GetManager creates and returns a database-specific Manager implementation based on the configuration.
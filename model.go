@@ -25,6 +25,9 @@ type Model struct {
 	DeleteDate *time.Time
 	// Optional custom table name override
 	tableName *string
+	// Values of the fields when the model was loaded or last saved. It is held
+	// by pointer so that structs embedding Model remain comparable
+	snapshot *snapshot
 }
 
 // CreateModel initializes a new Model instance with current timestamps.
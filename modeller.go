@@ -42,6 +42,7 @@ type Modeller interface {
 // Returns a slice of field definitions containing metadata about each field.
 func getDefs(t interface{}, first bool) []field {
 	res := make([]field, 0, 10)
+	tp := reflect.TypeOf(t)
 
 	// Add standard model fields if this is the top-level struct
 	if first {
@@ -66,32 +67,73 @@ func getDefs(t interface{}, first bool) []field {
 			fType:     tDateTime,
 			allowNull: true,
 		})
+		for i := range res {
+			if sf, ok := tp.FieldByName(res[i].name); ok {
+				res[i].path = sf.Index
+			}
+		}
 	}
 
-	// Use reflection to analyze the struct fields
-	v := reflect.ValueOf(t)
-	ft := reflect.TypeOf(t)
-	nf := v.NumField()
+	return append(res, structDefs(tp, nil, "", false)...)
+}
+
+// structDefs extracts the field definitions of a struct type.
+// Nested structs are flattened into the parent. Nested structs tagged
+// with embed have their column names prefixed, either with the prefix
+// option or with the name of the struct field.
+//
+// Parameters:
+//   - t: The struct type to analyze
+//   - path: The index path of the struct within the model
+//   - prefix: The prefix applied to column names
+//   - nullable: If true, the struct is reached through a pointer
+//
+// Returns a slice of field definitions containing metadata about each field.
+func structDefs(t reflect.Type, path []int, prefix string, nullable bool) []field {
+	res := make([]field, 0, 10)
+	nf := t.NumField()
 	for i := 0; i < nf; i++ {
-		st := ft.Field(i)
-		sv := v.Field(i)
-		null := false
+		st := t.Field(i)
+		sk := st.Type
+		null := nullable
+		idx := append(append(make([]int, 0, len(path)+1), path...), i)
 
 		// Handle pointer fields
-		if sv.Kind() == reflect.Ptr {
+		if sk.Kind() == reflect.Ptr {
 			null = true
-			sv = sv.Elem()
+			sk = sk.Elem()
 		}
 
+		tg, tagged := st.Tag.Lookup("mud")
+
 		// Handle nested structs (except time.Time)
-		if sv.Kind() == reflect.Struct && sv.Type().Name() != "Time" {
-			if subf := getDefs(sv.Interface(), false); len(subf) > 0 {
+		if sk.Kind() == reflect.Struct && sk.Name() != "Time" {
+			pfx := prefix
+			if tagged {
+				embed := false
+				sub := st.Name
+				for _, t := range splitTag(tg) {
+					pts := strings.SplitN(t, ":", 2)
+					switch pts[0] {
+					case "embed":
+						embed = true
+					case "prefix":
+						if len(pts) > 1 {
+							sub = pts[1]
+						}
+					}
+				}
+				if embed {
+					pfx += sub
+				}
+			}
+			if subf := structDefs(sk, idx, pfx, null); len(subf) > 0 {
 				res = append(res, subf...)
 			}
 		} else {
 			// Process mud tags for field configuration
-			if tg, ok := st.Tag.Lookup("mud"); ok {
-				nm := st.Name
+			if tagged {
+				nm := prefix + st.Name
 				szMj := 0      // Major size (e.g., varchar length)
 				szMn := 0      // Minor size (e.g., decimal places)
 				id := false    // Is identity field
@@ -99,14 +141,22 @@ func getDefs(t interface{}, first bool) []field {
 				uns := false   // Is unsigned
 				fld := tString // Default field type
 
+				var def *string  // Literal default value
+				dbDef := ""      // Database default expression
+				ro := false      // Is never written
+				insOnly := false // Is written on insert only
+				comp := ""       // Generated column expression
+				ten := false     // Is the tenant of the row
+				ft := false      // Is searched by full-text queries
+
 				// Find matching field type from reflection Kind
 			FieldSearchLoop:
 				for k, v := range fieldTrans {
 					for _, v2 := range v {
-						if v2 == sv.Kind() {
+						if v2 == sk.Kind() {
 							fld = k
 							for _, sn := range fieldUnsigned {
-								if sn == sv.Kind() {
+								if sn == sk.Kind() {
 									uns = true
 								}
 							}
@@ -117,17 +167,13 @@ func getDefs(t interface{}, first bool) []field {
 
 				// Parse field tags
 				if tg != "" {
-					tgs := strings.Split(tg, ",")
+					tgs := splitTag(tg)
 					for _, t := range tgs {
-						pts := strings.Split(t, ":")
+						pts := strings.SplitN(t, ":", 2)
 
 						switch pts[0] {
 						case "type":
-							typeKey := pts[1]
-							if typeKey == "time" {
-								typeKey = "struct"
-							}
-							if v, ok := fieldNames[typeKey]; ok {
+							if v, ok := ParseFieldType(pts[1]); ok {
 								fld = v
 							}
 						case "size":
@@ -146,13 +192,74 @@ func getDefs(t interface{}, first bool) []field {
 							key = true
 						case "unsigned":
 							uns = true
+						case "default":
+							if len(pts) > 1 {
+								def = &pts[1]
+							}
+						case "dbDefault":
+							if len(pts) > 1 {
+								dbDef = pts[1]
+							}
+						case "readonly":
+							ro = true
+						case "insertOnly":
+							insOnly = true
+						case "computed":
+							if len(pts) > 1 {
+								comp = pts[1]
+							}
+						case "tenant":
+							// Every query filters on the tenant, so it is always indexed
+							ten = true
+							key = true
+						case "fulltext":
+							ft = true
 						}
 
 					}
 				}
-				res = append(res, newField(nm, fld, szMj, szMn, id, key, uns, null))
+				f := newField(nm, fld, szMj, szMn, id, key, uns, null)
+				f.defValue = def
+				f.dbDefault = dbDef
+				f.readOnly = ro
+				f.insertOnly = insOnly
+				f.computed = comp
+				f.tenant = ten
+				f.fulltext = ft
+				f.path = idx
+				res = append(res, f)
 			}
 		}
 	}
 	return res
 }
+
+// splitTag splits a mud tag into its comma separated parts.
+// Commas within parentheses or quotes are ignored so that
+// expressions can be used as tag values.
+func splitTag(tg string) []string {
+	res := make([]string, 0, 5)
+	depth := 0
+	var quote rune
+	start := 0
+	for i, r := range tg {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			if depth > 0 {
+				depth--
+			}
+		case r == ',' && depth == 0:
+			res = append(res, tg[start:i])
+			start = i + 1
+		}
+	}
+	return append(res, tg[start:])
+}
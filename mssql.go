@@ -4,7 +4,15 @@
 // Package mud provides a simple ORM implementation for MS SQL Server databases.
 package mud
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/markoxley/mud/utils"
+)
 
 // MSSQLManager implements the database management interface for Microsoft SQL Server.
 // It handles SQL Server specific query generation and database operations.
@@ -34,37 +42,60 @@ func (m *MSSQLManager) ConnectionString(cfg *Config) (string, error) {
 	if cfg.User == "" || cfg.Password == "" || cfg.Host == "" || cfg.Database == "" {
 		return "", fmt.Errorf("invalid config provided")
 	}
+	// go-mssqldb has no read timeout, and its connection timeout limits the login
+	if cfg.ReadTimeout > 0 {
+		return "", fmt.Errorf("read timeout is not supported by SQL Server, use a context deadline")
+	}
 	m.dbName = cfg.Database
-	return fmt.Sprintf("sqlserver://%s:%s@%s?database=%s", cfg.User, cfg.Password, cfg.Host, cfg.Database), nil
+	params := url.Values{}
+	params.Set("database", cfg.Database)
+	switch cfg.TLS {
+	case TLSDisable:
+		params.Set("encrypt", "disable")
+	case TLSPrefer:
+		params.Set("encrypt", "false")
+	case TLSRequire:
+		params.Set("encrypt", "true")
+		params.Set("TrustServerCertificate", "true")
+	case TLSVerify:
+		params.Set("encrypt", "true")
+	}
+	// SQL Server timeouts are specified in whole seconds
+	if cfg.ConnectTimeout > 0 {
+		params.Set("dial timeout", strconv.Itoa(int(math.Ceil(cfg.ConnectTimeout.Seconds()))))
+	}
+	u := url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     cfg.address(),
+		RawQuery: cfg.options(params),
+	}
+	return u.String(), nil
 }
 
 // LimitString generates the SQL Server specific FETCH NEXT clause for result limiting.
 // Returns an empty string if criteria is nil or limit is less than 1.
+// FETCH NEXT must follow the OFFSET clause, which OffsetString includes whenever a limit is set.
 func (m *MSSQLManager) LimitString(c *Criteria) string {
 	if c == nil || c.Limit < 1 {
 		return ""
 	}
-	res := ""
-	if c.Order == nil {
-		res = fmt.Sprintf(" ORDER BY [ID] ")
-	}
-	if c.Offset < 1 {
-		return fmt.Sprintf("%s OFFSET 0 ROWS FETCH NEXT %d ROWS ONLY", res, c.Limit)
-	}
-	return fmt.Sprintf("%s OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", res, c.Offset, c.Limit)
+	return fmt.Sprintf(" FETCH NEXT %d ROWS ONLY", c.Limit)
 }
 
 // OffsetString generates the SQL Server specific OFFSET clause.
-// Returns an empty string if criteria is nil or offset is less than 1.
+// Returns an empty string if criteria is nil or neither an offset nor a limit is set.
+// SQL Server only allows OFFSET after an ORDER BY, so queries without an order are
+// ordered by ID.
 func (m *MSSQLManager) OffsetString(c *Criteria) string {
-	if c == nil || c.Offset < 1 {
+	if c == nil || (c.Offset < 1 && c.Limit < 1) {
 		return ""
 	}
 	res := ""
-	if c.Order == nil {
-		res = fmt.Sprintf(" ORDER BY [ID] ")
+	if c.OrderString(m) == "" {
+		res = fmt.Sprintf(" ORDER BY %s", m.IdentityString("ID"))
 	}
-	return fmt.Sprintf("%s OFFSET %d ROWS", res, c.Offset)
+	return fmt.Sprintf("%s OFFSET %d ROWS", res, max(c.Offset, 0))
 }
 
 // IdentityString wraps a field name in square brackets for SQL Server identifier escaping.
@@ -90,23 +121,31 @@ func (m *MSSQLManager) TableExistsQuery(name string) string {
 }
 
 // Operators returns a list of SQL Server compatible operator formats for query building.
-// These formats include comparison, LIKE, IN, BETWEEN, and NULL check operators.
+// These formats include comparison, LIKE, IN, BETWEEN, NULL check and case-insensitive operators.
 func (m *MSSQLManager) Operators() []string {
 	return []string{
-		"[%s] = %s",                  // Equal
-		"[%s] > %s",                  // Greater than
-		"[%s] < %s",                  // Less than
-		"[%s] LIKE %s",               // Pattern matching
-		"[%s] IN (%s)",               // In list
-		"[%s] BETWEEN %s AND %s",     // Between range
-		"[%s] IS NULL",               // Is null check
-		"[%s] <> %s",                 // Not equal
-		"[%s] <= %s",                 // Less than or equal
-		"[%s] >= %s",                 // Greater than or equal
-		"[%s] NOT LIKE %s",           // Not like pattern
-		"[%s] NOT IN (%s)",           // Not in list
-		"[%s] NOT BETWEEN %s AND %s", // Not between range
-		"[%s] IS NOT NULL",           // Is not null check
+		"[%s] = %s",                              // Equal
+		"[%s] > %s",                              // Greater than
+		"[%s] < %s",                              // Less than
+		"[%s] LIKE %s",                           // Pattern matching
+		"[%s] IN (%s)",                           // In list
+		"[%s] BETWEEN %s AND %s",                 // Between range
+		"[%s] IS NULL",                           // Is null check
+		"[%s] LIKE %s ESCAPE '\\'",               // Escaped pattern matching
+		"LOWER([%s]) = LOWER(%s)",                // Case-insensitive equal
+		"LOWER([%s]) LIKE LOWER(%s) ESCAPE '\\'", // Case-insensitive pattern matching
+		"FREETEXT([%s], %s)",                     // Full-text search
+		"[%s] <> %s",                             // Not equal
+		"[%s] <= %s",                             // Less than or equal
+		"[%s] >= %s",                             // Greater than or equal
+		"[%s] NOT LIKE %s",                       // Not like pattern
+		"[%s] NOT IN (%s)",                       // Not in list
+		"[%s] NOT BETWEEN %s AND %s",             // Not between range
+		"[%s] IS NOT NULL",                       // Is not null check
+		"[%s] NOT LIKE %s ESCAPE '\\'",           // Not escaped pattern
+		"LOWER([%s]) <> LOWER(%s)",               // Case-insensitive not equal
+		"LOWER([%s]) NOT LIKE LOWER(%s) ESCAPE '\\'", // Case-insensitive not like pattern
+		"NOT FREETEXT([%s], %s)",                     // Not full-text search
 	}
 }
 
@@ -120,3 +159,105 @@ func (m *MSSQLManager) TableCreate() string {
 func (m *MSSQLManager) IndexCreate() string {
 	return "CREATE INDEX [%s_%s_Idx] ON [%s]([%s]);"
 }
+
+// FullTextCreate returns the statements that create a full-text index on the
+// fields, in the default catalog of mud. SQL Server allows one full-text index
+// per table, keyed by a unique index on ID.
+func (m *MSSQLManager) FullTextCreate(table string, fields []string) []string {
+	cols := make([]string, 0, len(fields))
+	for _, f := range fields {
+		cols = append(cols, m.IdentityString(f))
+	}
+	key := m.IdentityString(strings.ReplaceAll(table, ".", "_") + "_ID_Ft")
+	return []string{
+		"IF NOT EXISTS (SELECT 1 FROM sys.fulltext_catalogs WHERE name = N'mud') EXEC('CREATE FULLTEXT CATALOG [mud]');",
+		fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s([ID]);", key, m.IdentityString(table)),
+		fmt.Sprintf("CREATE FULLTEXT INDEX ON %s(%s) KEY INDEX %s ON [mud];", m.IdentityString(table), strings.Join(cols, ", "), key),
+	}
+}
+
+// RelevanceOrder returns the ORDER BY term that sorts rows by the FREETEXTTABLE
+// rank of the field against the query. Rows that do not match are sorted last.
+func (m *MSSQLManager) RelevanceOrder(table string, field string, query string) string {
+	q := strings.Join(strings.Fields(query), " ")
+	if q == "" {
+		return ""
+	}
+	v, _ := m.MakeValue(q)
+	return fmt.Sprintf("(SELECT ft.[RANK] FROM FREETEXTTABLE(%s, %s, %s) AS ft WHERE ft.[KEY] = %s.[ID]) DESC", m.IdentityString(table), m.IdentityString(field), v, m.IdentityString(table))
+}
+
+// ColumnType returns the SQL Server column definition for a field.
+// SQL Server has no unsigned types, so unsigned integers are widened to
+// the next type that can hold their full range. Computed columns are persisted
+// and take their type from the expression.
+func (m *MSSQLManager) ColumnType(c Column) string {
+	if c.Computed != "" {
+		return fmt.Sprintf("AS (%s) PERSISTED", c.Computed)
+	}
+	if c.AutoIncrement {
+		return "BIGINT IDENTITY(1,1) NOT NULL PRIMARY KEY"
+	}
+	var res string
+	switch c.Type {
+	case tInt:
+		res = "INT"
+		if c.Unsigned {
+			res = "BIGINT"
+		}
+	case tLong:
+		res = "BIGINT"
+		if c.Unsigned {
+			res = "DECIMAL(20,0)"
+		}
+	case tBool:
+		res = "BIT"
+	case tDecimal:
+		res = "DECIMAL"
+		if c.Size.Size > 0 {
+			res += fmt.Sprintf("(%s)", c.Size)
+		}
+	case tFloat:
+		res = "REAL"
+	case tDouble:
+		res = "FLOAT"
+	case tDateTime:
+		res = "DATETIME2"
+	case tChar:
+		res = "NCHAR(1)"
+	case tUUID:
+		res = "VARCHAR(36)"
+	default:
+		switch sz := c.Size.Size; {
+		case sz == 0:
+			res = "NVARCHAR(256)"
+		case sz > 4000:
+			res = "NVARCHAR(MAX)"
+		default:
+			res = fmt.Sprintf("NVARCHAR(%d)", sz)
+		}
+	}
+	if c.AllowNull {
+		res += " NULL"
+	} else {
+		res += " NOT NULL"
+	}
+	return res
+}
+
+// MakeValue converts a value to a SQL Server literal.
+// Strings are written as Unicode literals to match the NVARCHAR columns.
+func (m *MSSQLManager) MakeValue(value interface{}) (string, bool) {
+	vl, ok := utils.MakeValue(value)
+	if ok {
+		if _, isStr := value.(string); isStr {
+			vl = "N" + vl
+		}
+	}
+	return vl, ok
+}
+
+// InsertReturning generates an INSERT statement with an OUTPUT clause for the key column.
+func (m *MSSQLManager) InsertReturning(table string, fields string, values string, key string) (string, bool) {
+	return fmt.Sprintf("INSERT INTO %s (%s) OUTPUT INSERTED.%s VALUES(%s)", table, fields, key, values), true
+}
@@ -6,6 +6,10 @@ package mud
 
 import (
 	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/markoxley/mud/utils"
 )
 
 // MySQLManager implements the database management interface for MySQL.
@@ -37,7 +41,28 @@ func (m *MySQLManager) ConnectionString(cfg *Config) (string, error) {
 		return "", fmt.Errorf("invalid config provided")
 	}
 	m.dbName = cfg.Database
-	return fmt.Sprintf("%s:%s@tcp(%s)/%s", cfg.User, cfg.Password, cfg.Host, cfg.Database), nil
+	params := url.Values{}
+	switch cfg.TLS {
+	case TLSDisable:
+		params.Set("tls", "false")
+	case TLSPrefer:
+		params.Set("tls", "preferred")
+	case TLSRequire:
+		params.Set("tls", "skip-verify")
+	case TLSVerify:
+		params.Set("tls", "true")
+	}
+	if cfg.ConnectTimeout > 0 {
+		params.Set("timeout", cfg.ConnectTimeout.String())
+	}
+	if cfg.ReadTimeout > 0 {
+		params.Set("readTimeout", cfg.ReadTimeout.String())
+	}
+	cs := fmt.Sprintf("%s:%s@tcp(%s)/%s", cfg.User, cfg.Password, cfg.address(), cfg.Database)
+	if opts := cfg.options(params); opts != "" {
+		cs += "?" + opts
+	}
+	return cs, nil
 }
 
 // LimitString generates the MySQL specific LIMIT clause for result limiting.
@@ -74,6 +99,29 @@ func (m *MySQLManager) IndexCreate() string {
 	return "CREATE INDEX `%s_%s_Idx` ON %s(`%s`);"
 }
 
+// FullTextCreate returns the statements that create a FULLTEXT index on each
+// of the fields. MATCH requires an index on exactly the columns it searches,
+// so the fields are indexed separately.
+func (m *MySQLManager) FullTextCreate(table string, fields []string) []string {
+	res := make([]string, 0, len(fields))
+	kn := strings.ReplaceAll(table, ".", "_")
+	for _, f := range fields {
+		res = append(res, fmt.Sprintf("CREATE FULLTEXT INDEX `%s_%s_Ft` ON `%s`(`%s`);", kn, f, table, f))
+	}
+	return res
+}
+
+// RelevanceOrder returns the ORDER BY term that sorts rows by the natural
+// language relevance of the field to the query.
+func (m *MySQLManager) RelevanceOrder(table string, field string, query string) string {
+	q := strings.Join(strings.Fields(query), " ")
+	if q == "" {
+		return ""
+	}
+	v, _ := m.MakeValue(q)
+	return fmt.Sprintf("MATCH(`%s`) AGAINST (%s IN NATURAL LANGUAGE MODE) DESC", field, v)
+}
+
 // BuildQuery combines WHERE, ORDER BY, LIMIT, and OFFSET clauses into a complete query string.
 func (m *MySQLManager) BuildQuery(where string, order string, limit string, offset string) string {
 	res := ""
@@ -92,22 +140,98 @@ func (m *MySQLManager) TableExistsQuery(name string) string {
 }
 
 // Operators returns a list of MySQL compatible operator formats for query building.
-// These formats include comparison, LIKE, IN, BETWEEN, and NULL check operators.
+// These formats include comparison, LIKE, IN, BETWEEN, NULL check and case-insensitive operators.
+// Escaped patterns use ! as the escape character, as a backslash is itself an escape in MySQL strings.
 func (m *MySQLManager) Operators() []string {
 	return []string{
-		"`%s` = %s",                  // Equal
-		"`%s` > %s",                  // Greater than
-		"`%s` < %s",                  // Less than
-		"`%s` LIKE %s",               // Pattern matching
-		"`%s` IN (%s)",               // In list
-		"`%s` BETWEEN %s AND %s",     // Between range
-		"`%s` IS NULL",               // Is null check
-		"`%s` <> %s",                 // Not equal
-		"`%s` <= %s",                 // Less than or equal
-		"`%s` >= %s",                 // Greater than or equal
-		"`%s` NOT LIKE %s",           // Not like pattern
-		"`%s` NOT IN (%s)",           // Not in list
-		"`%s` NOT BETWEEN %s AND %s", // Not between range
-		"`%s` IS NOT NULL",           // Is not null check
+		"`%s` = %s",                             // Equal
+		"`%s` > %s",                             // Greater than
+		"`%s` < %s",                             // Less than
+		"`%s` LIKE %s",                          // Pattern matching
+		"`%s` IN (%s)",                          // In list
+		"`%s` BETWEEN %s AND %s",                // Between range
+		"`%s` IS NULL",                          // Is null check
+		"`%s` LIKE %s ESCAPE '!'",               // Escaped pattern matching
+		"LOWER(`%s`) = LOWER(%s)",               // Case-insensitive equal
+		"LOWER(`%s`) LIKE LOWER(%s) ESCAPE '!'", // Case-insensitive pattern matching
+		"MATCH(`%s`) AGAINST (%s IN NATURAL LANGUAGE MODE)", // Full-text search
+		"`%s` <> %s",                                // Not equal
+		"`%s` <= %s",                                // Less than or equal
+		"`%s` >= %s",                                // Greater than or equal
+		"`%s` NOT LIKE %s",                          // Not like pattern
+		"`%s` NOT IN (%s)",                          // Not in list
+		"`%s` NOT BETWEEN %s AND %s",                // Not between range
+		"`%s` IS NOT NULL",                          // Is not null check
+		"`%s` NOT LIKE %s ESCAPE '!'",               // Not escaped pattern
+		"LOWER(`%s`) <> LOWER(%s)",                  // Case-insensitive not equal
+		"LOWER(`%s`) NOT LIKE LOWER(%s) ESCAPE '!'", // Case-insensitive not like pattern
+		"NOT MATCH(`%s`) AGAINST (%s IN NATURAL LANGUAGE MODE)", // Not full-text search
 	}
 }
+
+// ColumnType returns the MySQL column definition for a field.
+// Strings longer than a VARCHAR can hold in utf8mb4 are stored as LONGTEXT.
+// Computed columns are stored generated columns.
+func (m *MySQLManager) ColumnType(c Column) string {
+	if c.AutoIncrement {
+		return "BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY"
+	}
+	var res string
+	switch c.Type {
+	case tInt:
+		res = "INT"
+	case tLong:
+		res = "BIGINT"
+	case tBool:
+		res = "TINYINT(1)"
+	case tDecimal:
+		res = "DECIMAL"
+		if c.Size.Size > 0 {
+			res += fmt.Sprintf("(%s)", c.Size)
+		}
+	case tFloat:
+		res = "FLOAT"
+	case tDouble:
+		res = "DOUBLE"
+	case tDateTime:
+		res = "DATETIME(3)"
+	case tChar:
+		res = "CHAR(1)"
+	case tUUID:
+		res = "VARCHAR(36)"
+	default:
+		switch sz := c.Size.Size; {
+		case sz == 0:
+			res = "VARCHAR(256)"
+		case sz > 16383:
+			res = "LONGTEXT"
+		default:
+			res = fmt.Sprintf("VARCHAR(%d)", sz)
+		}
+	}
+	if c.Unsigned && (c.Type == tInt || c.Type == tLong) {
+		res += " UNSIGNED"
+	}
+	if c.Computed != "" {
+		return fmt.Sprintf("%s GENERATED ALWAYS AS (%s) STORED", res, c.Computed)
+	}
+	if !c.AllowNull {
+		res += " NOT NULL"
+	}
+	return res
+}
+
+// MakeValue converts a value to a MySQL literal.
+// Backslashes are escaped as MySQL treats them as escape characters in strings.
+func (m *MySQLManager) MakeValue(value interface{}) (string, bool) {
+	if s, ok := value.(string); ok {
+		value = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return utils.MakeValue(value)
+}
+
+// InsertReturning generates a plain INSERT statement, as MySQL cannot return
+// the key from the statement. The key is read from LastInsertId instead.
+func (m *MySQLManager) InsertReturning(table string, fields string, values string, key string) (string, bool) {
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES(%s)", table, fields, values), false
+}
@@ -15,6 +15,9 @@ type Builder struct {
 type order struct {
 	field     string
 	ascending bool
+	// query is the full-text query the field is ranked against, for relevance orderings
+	query     string
+	relevance bool
 }
 
 func newOrder(field string, direction bool) order {
@@ -51,6 +54,15 @@ func Asc(field string) *Builder {
 	return b
 }
 
+// Relevance creates a new Builder with the first ordering being the full-text
+// relevance of the field to the query, most relevant first. The field must be
+// tagged fulltext
+func Relevance(field string, query string) *Builder {
+	b := newBuilder()
+	b.fields = append(b.fields, order{field: field, query: query, relevance: true})
+	return b
+}
+
 // Desc add a field being descending
 func (b *Builder) Desc(field string) *Builder {
 	b.fields = append(b.fields, newOrder(field, false))
@@ -63,10 +75,21 @@ func (b *Builder) Asc(field string) *Builder {
 	return b
 }
 
-// String returns the string version of the ordering list
+// Relevance add an ordering by the full-text relevance of the field to the query,
+// most relevant first
+func (b *Builder) Relevance(field string, query string) *Builder {
+	b.fields = append(b.fields, order{field: field, query: query, relevance: true})
+	return b
+}
+
+// String returns the string version of the ordering list. Relevance
+// orderings are left out, as they cannot be rendered without the dialect
 func (b *Builder) String() string {
 	r := ""
 	for _, o := range b.fields {
+		if o.relevance {
+			continue
+		}
 		if r != "" {
 			r += ", "
 		}
@@ -75,3 +98,59 @@ func (b *Builder) String() string {
 	return r
 }
 
+// Field describes a field of the ordering list
+type Field struct {
+	// Name is the name of the field
+	Name string
+	// Ascending is true if the field is sorted in ascending order
+	Ascending bool
+	// Relevance is true if the field is ordered by its full-text relevance to Query
+	Relevance bool
+	// Query is the full-text query of a relevance ordering
+	Query string
+}
+
+// Fields returns the fields of the ordering list, in order
+func (b *Builder) Fields() []Field {
+	res := make([]Field, 0, len(b.fields))
+	for _, o := range b.fields {
+		res = append(res, Field{Name: o.field, Ascending: o.ascending, Relevance: o.relevance, Query: o.query})
+	}
+	return res
+}
+
+// Render returns the ordering list with the field names escaped by identity,
+// so that the list can be used with any database dialect. Relevance
+// orderings are left out, as they cannot be rendered without the dialect
+func (b *Builder) Render(identity func(string) string) string {
+	return b.RenderWith(identity, nil)
+}
+
+// RenderWith returns the ordering list with the field names escaped by identity,
+// and relevance orderings rendered by relevance, which returns the complete
+// ORDER BY term for a field and query, or an empty string to leave it out
+func (b *Builder) RenderWith(identity func(string) string, relevance func(field string, query string) string) string {
+	r := ""
+	for _, o := range b.fields {
+		var t string
+		if o.relevance {
+			if relevance == nil {
+				continue
+			}
+			if t = relevance(o.field, o.query); t == "" {
+				continue
+			}
+		} else {
+			d := "asc"
+			if !o.ascending {
+				d = "desc"
+			}
+			t = fmt.Sprintf("%s %s", identity(o.field), d)
+		}
+		if r != "" {
+			r += ", "
+		}
+		r += t
+	}
+	return r
+}
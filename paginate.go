@@ -0,0 +1,142 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+
+// Package mud provides offset pagination of models.
+package mud
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/markoxley/mud/order"
+	"github.com/markoxley/mud/utils"
+)
+
+// Page holds a page of models along with the position of the page in the result set.
+type Page[T Modeller] struct {
+	// Items holds the models on the page
+	Items []*T
+	// Page is the number of the page, starting at 1
+	Page int
+	// PerPage is the maximum number of models on a page
+	PerPage int
+	// Total is the number of models matching the criteria across all pages
+	Total int
+	// Pages is the number of pages
+	Pages int
+	// HasNext is true if there is a page after this one
+	HasNext bool
+	// HasPrev is true if there is a page before this one
+	HasPrev bool
+}
+
+// Paginate returns a page of the models that match the criteria, along with
+// the total number of matching models. The count and the page are read within
+// one transaction, unless transactions are disabled, so that they are consistent.
+// Pages are numbered from 1, and ID is added to the order as a final tiebreaker,
+// unless the order already includes it, so that pages are stable. Any limit or offset in the criteria is
+// replaced, and the cache is not used.
+// @param db
+// @param page
+// @param perPage
+// @param criteria
+// @return *Page[T]
+// @return error
+func Paginate[T Modeller](db *DB, page int, perPage int, criteria ...interface{}) (*Page[T], error) {
+	if perPage < 1 {
+		return nil, fmt.Errorf("invalid page size: %d", perPage)
+	}
+	if page < 1 {
+		page = 1
+	}
+	c, err := db.getCriteria(criteria)
+	if err != nil {
+		return nil, err
+	}
+	m := *new(T)
+	// The default scope may supply the order
+	pc := *db.defaultScope(m, c)
+	pc.table = GetTableName(m)
+	ord := strings.TrimPrefix(pc.OrderString(db.mgr), " ORDER BY ")
+	switch {
+	case ord == "":
+		ord = db.mgr.IdentityString("ID")
+	case !ordersByID(pc.Order):
+		// Rows with equal sort values would otherwise move between pages
+		ord += fmt.Sprintf(", %s asc", db.mgr.IdentityString("ID"))
+	}
+	pc.Order = ord
+	pc.Limit = perPage
+	pc.Offset = (page - 1) * perPage
+
+	// Create the table before the transaction, so that DDL is not mixed with the reads
+	if _, _, err := db.tableTest(m); err != nil {
+		return nil, err
+	}
+
+	var tx []*sql.Tx
+	if !db.cfg.DisabledTransactions {
+		qtx, err := db.beginTransaction(db.pool(Operation{Name: OpSelect, Table: GetTableName(m)}))
+		if err != nil {
+			return nil, err
+		}
+		defer db.RollbackTransaction(qtx)
+		tx = append(tx, qtx)
+	}
+
+	total, err := db.count(m, &pc, tx...)
+	if err != nil {
+		return nil, err
+	}
+	res := &Page[T]{
+		Items:   make([]*T, 0, perPage),
+		Page:    page,
+		PerPage: perPage,
+		Total:   total,
+		Pages:   (total + perPage - 1) / perPage,
+	}
+	res.HasPrev = page > 1
+	res.HasNext = page < res.Pages
+
+	if pc.Offset < total {
+		ms, err := db.fetch(m, &pc, tx...)
+		if err != nil {
+			return nil, err
+		}
+		for _, mdl := range ms {
+			res.Items = append(res.Items, utils.Ptr(mdl.(T)))
+		}
+	}
+	if len(tx) > 0 {
+		if err := db.CommitTransaction(tx[0]); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// idRegex matches the ID column in an order given as a string
+var idRegex = regexp.MustCompile(`(?i)\bID\b`)
+
+// ordersByID reports whether the order includes the ID column
+// @param o
+// @return bool
+func ordersByID(o interface{}) bool {
+	switch v := o.(type) {
+	case *order.Builder:
+		for _, f := range v.Fields() {
+			if !f.Relevance && strings.EqualFold(f.Name, "ID") {
+				return true
+			}
+		}
+		return false
+	case string:
+		return idRegex.MatchString(v)
+	case fmt.Stringer:
+		return idRegex.MatchString(v.String())
+	}
+	return false
+}
@@ -0,0 +1,251 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+
+// Package mud provides routing of reads to replicas of the database.
+package mud
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultHealthCheckInterval is the time between health checks of the
+// replicas when Config.HealthCheckInterval is not set
+const DefaultHealthCheckInterval = 10 * time.Second
+
+// healthCheckTimeout limits the time a replica has to answer a health check,
+// so that an unreachable replica does not hold up New
+const healthCheckTimeout = 2 * time.Second
+
+// Replica is a read replica of the database.
+// Replicas that fail a health check, or whose connection fails during a
+// read, are ejected until they pass a later health check.
+type Replica struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// Name returns the address of the replica
+func (r *Replica) Name() string {
+	return r.name
+}
+
+// Healthy returns true if the replica is receiving reads
+func (r *Replica) Healthy() bool {
+	return r.healthy.Load()
+}
+
+// Stats returns the statistics of the connection pool of the replica
+func (r *Replica) Stats() sql.DBStats {
+	return r.db.Stats()
+}
+
+// Balancer chooses the replica that serves a read.
+// Pick is only called with healthy replicas, and always at least one.
+type Balancer interface {
+	Pick(replicas []*Replica) *Replica
+}
+
+// BalancerFunc is a function that implements Balancer
+type BalancerFunc func(replicas []*Replica) *Replica
+
+// Pick calls the function
+func (f BalancerFunc) Pick(replicas []*Replica) *Replica {
+	return f(replicas)
+}
+
+// roundRobin takes each replica in turn
+type roundRobin struct {
+	next atomic.Uint64
+}
+
+// Pick returns the next replica in turn
+func (b *roundRobin) Pick(replicas []*Replica) *Replica {
+	return replicas[(b.next.Add(1)-1)%uint64(len(replicas))]
+}
+
+// RoundRobin returns a Balancer that uses each replica in turn.
+// This is the default balancer.
+func RoundRobin() Balancer {
+	return &roundRobin{}
+}
+
+// Random returns a Balancer that chooses a replica at random
+func Random() Balancer {
+	return BalancerFunc(func(replicas []*Replica) *Replica {
+		return replicas[rand.IntN(len(replicas))]
+	})
+}
+
+// LeastConnections returns a Balancer that chooses the replica with the
+// fewest connections in use
+func LeastConnections() Balancer {
+	return BalancerFunc(func(replicas []*Replica) *Replica {
+		res := replicas[0]
+		use := res.Stats().InUse
+		for _, r := range replicas[1:] {
+			if n := r.Stats().InUse; n < use {
+				res, use = r, n
+			}
+		}
+		return res
+	})
+}
+
+// healthCheck stops the health checks of the replicas when the database is closed
+type healthCheck struct {
+	stop chan struct{}
+	once sync.Once
+}
+
+// SetBalancer sets the balancer that chooses the replica for each read.
+// Passing nil restores the default round robin balancer.
+// @param b
+func (db *DB) SetBalancer(b Balancer) {
+	if b == nil {
+		b = RoundRobin()
+	}
+	db.balancer = b
+}
+
+// UsePrimary returns a handle to the database whose reads go to the primary,
+// for reads that must see the handle's own recent writes.
+// @return *DB
+func (db *DB) UsePrimary() *DB {
+	c := *db
+	c.primary = true
+	c.derived = true
+	return &c
+}
+
+// Replicas returns the read replicas of the database
+// @return []*Replica
+func (db *DB) Replicas() []*Replica {
+	return db.replicas
+}
+
+// openReplicas connects to the replicas in the config, checks their health,
+// and starts checking them periodically
+// @return error
+func (db *DB) openReplicas() error {
+	if len(db.cfg.Replicas) == 0 {
+		return nil
+	}
+	for _, rc := range db.cfg.Replicas {
+		cfg := db.cfg.replica(rc)
+		if cfg.Type != db.cfg.Type {
+			db.closeReplicas()
+			return fmt.Errorf("replica type %s does not match %s", cfg.Type, db.cfg.Type)
+		}
+		cs, err := db.mgr.ConnectionString(cfg)
+		if err != nil {
+			db.closeReplicas()
+			return err
+		}
+		d, err := db.connect(cfg, cs)
+		if err != nil {
+			db.closeReplicas()
+			return err
+		}
+		name := cfg.Database
+		if a := cfg.address(); a != "" {
+			name = a + "/" + name
+		}
+		db.replicas = append(db.replicas, &Replica{name: name, db: d})
+	}
+	db.checkReplicas()
+
+	iv := db.cfg.HealthCheckInterval
+	if iv <= 0 {
+		iv = DefaultHealthCheckInterval
+	}
+	db.health = &healthCheck{stop: make(chan struct{})}
+	go func(stop chan struct{}) {
+		t := time.NewTicker(iv)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+				db.checkReplicas()
+			}
+		}
+	}(db.health.stop)
+	return nil
+}
+
+// checkReplicas pings the replicas at the same time, ejecting those that fail
+// and restoring those that pass. Each ping is limited to the shorter of the
+// health check interval and healthCheckTimeout
+func (db *DB) checkReplicas() {
+	to := db.cfg.HealthCheckInterval
+	if to <= 0 || to > healthCheckTimeout {
+		to = healthCheckTimeout
+	}
+	var wg sync.WaitGroup
+	for _, r := range db.replicas {
+		wg.Add(1)
+		go func(r *Replica) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), to)
+			defer cancel()
+			r.healthy.Store(r.db.PingContext(ctx) == nil)
+		}(r)
+	}
+	wg.Wait()
+}
+
+// closeReplicas stops the health checks and disconnects from the replicas
+func (db *DB) closeReplicas() {
+	if db.health != nil {
+		db.health.once.Do(func() { close(db.health.stop) })
+	}
+	for _, r := range db.replicas {
+		db.disconnect(r.db)
+	}
+}
+
+// pool returns the connection pool that runs the operation.
+// Selects and counts go to a healthy replica, unless the handle uses the
+// primary. Everything else, and every read when no replica is healthy,
+// goes to the primary
+// @param op
+// @return *sql.DB
+func (db *DB) pool(op Operation) *sql.DB {
+	if db.primary || len(db.replicas) == 0 || (op.Name != OpSelect && op.Name != OpCount) {
+		return db.db
+	}
+	healthy := make([]*Replica, 0, len(db.replicas))
+	for _, r := range db.replicas {
+		if r.Healthy() {
+			healthy = append(healthy, r)
+		}
+	}
+	if len(healthy) == 0 {
+		return db.db
+	}
+	return db.balancer.Pick(healthy).db
+}
+
+// replicaFailed ejects the replica using the connection pool if the error
+// shows that its connection failed
+// @param d
+// @param err
+func (db *DB) replicaFailed(d *sql.DB, err error) {
+	if err == nil || d == db.db || ClassifyError(err) != ClassConnection {
+		return
+	}
+	for _, r := range db.replicas {
+		if r.db == d {
+			r.healthy.Store(false)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+
+// Package mud provides default scopes for models.
+package mud
+
+import "reflect"
+
+// Scope is a filter and ordering applied to every query of a model.
+type Scope struct {
+	// Where is a condition, as a where.Builder or a string, that is combined
+	// with the condition of each query
+	Where interface{}
+	// Order is the ordering, as an order.Builder or a string, of queries that
+	// do not specify their own
+	Order interface{}
+}
+
+// Scoper is implemented by models with a default scope. The scope is merged
+// into the criteria of Fetch, First, Count, Range, the pagination helpers and
+// RemoveMany, unless the criteria is Unscoped.
+type Scoper interface {
+	DefaultScope() Scope
+}
+
+// defaultScope returns the criteria with the default scope of the model
+// merged in. The returned criteria is marked as unscoped, so that the scope
+// is only applied once
+// @param m
+// @param c
+// @return *Criteria
+func (db *DB) defaultScope(m Modeller, c *Criteria) *Criteria {
+	if c == nil {
+		c = &Criteria{}
+	}
+	if c.unscoped {
+		return c
+	}
+	sc, ok := m.(Scoper)
+	if t := reflect.TypeOf(m); !ok && t.Kind() != reflect.Pointer {
+		// Scopes are usually declared on the pointer receiver
+		sc, ok = reflect.New(t).Interface().(Scoper)
+	}
+	if !ok {
+		return c
+	}
+	s := sc.DefaultScope()
+	res := c.Unscoped()
+	if w := whereString(db.mgr, s.Where); w != "" {
+		res = res.withWhere(w)
+	}
+	if s.Order != nil && res.OrderString(db.mgr) == "" {
+		res.Order = s.Order
+	}
+	return res
+}
+
+// scope returns the criteria with the default scope and tenant of the model
+// applied, to it and to its subqueries, bound to the table of the model so
+// that full-text conditions can refer to it
+// @param m
+// @param flds
+// @param c
+// @return *Criteria
+// @return error
+func (db *DB) scope(m Modeller, flds []field, c *Criteria) (*Criteria, error) {
+	c, err := db.tenantScope(flds, db.defaultScope(m, c))
+	if err != nil {
+		return nil, err
+	}
+	res := *c
+	res.table = GetTableName(m)
+	if res.Where, res.subqueries, err = db.scopeQueries(res.Where); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -4,7 +4,13 @@
 // Package mud provides a simple ORM implementation for SQLite databases.
 package mud
 
-import "fmt"
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/markoxley/mud/utils"
+)
 
 // SqliteManager implements the database management interface for SQLite.
 // It handles SQLite specific query generation and database operations.
@@ -32,7 +38,15 @@ func (m *SqliteManager) ConnectionString(cfg *Config) (string, error) {
 	if cfg.Database == "" {
 		return "", fmt.Errorf("invalid config provided")
 	}
-	return cfg.Database, nil
+	// SQLite databases are local files, so only the driver options apply
+	opts := cfg.options(url.Values{})
+	if opts == "" {
+		return cfg.Database, nil
+	}
+	if strings.Contains(cfg.Database, "?") {
+		return cfg.Database + "&" + opts, nil
+	}
+	return cfg.Database + "?" + opts, nil
 }
 
 // LimitString generates the SQLite specific LIMIT clause for result limiting.
@@ -69,6 +83,44 @@ func (m *SqliteManager) IndexCreate() string {
 	return "CREATE INDEX \"%s_%s_Idx\" ON %s(\"%s\");"
 }
 
+// FullTextCreate returns the statements that create an FTS5 table holding the
+// text of the fields, along with the triggers that keep it in step with the table.
+// The FTS5 table keeps its own copy of the text, keyed by ID, as the rowid of
+// the table may change when the database is vacuumed.
+func (m *SqliteManager) FullTextCreate(table string, fields []string) []string {
+	fts := m.IdentityString(table + "_fts")
+	cols := make([]string, 0, len(fields))
+	vals := make([]string, 0, len(fields))
+	for _, f := range fields {
+		cols = append(cols, m.IdentityString(f))
+		vals = append(vals, "new."+m.IdentityString(f))
+	}
+	c := strings.Join(cols, ", ")
+	ins := fmt.Sprintf("INSERT INTO %s(\"ID\", %s) VALUES (new.\"ID\", %s);", fts, c, strings.Join(vals, ", "))
+	del := fmt.Sprintf("DELETE FROM %s WHERE \"ID\" = old.\"ID\";", fts)
+	return []string{
+		fmt.Sprintf("CREATE VIRTUAL TABLE IF NOT EXISTS %s USING fts5(\"ID\" UNINDEXED, %s);", fts, c),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s AFTER INSERT ON %s BEGIN %s END;", m.IdentityString(table+"_fts_insert"), m.IdentityString(table), ins),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s AFTER UPDATE OF %s ON %s BEGIN %s %s END;", m.IdentityString(table+"_fts_update"), c, m.IdentityString(table), del, ins),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s AFTER DELETE ON %s BEGIN %s END;", m.IdentityString(table+"_fts_delete"), m.IdentityString(table), del),
+	}
+}
+
+// RelevanceOrder returns the ORDER BY term that sorts rows by the FTS5 rank of
+// the field against the query. Rows that do not match are sorted last.
+func (m *SqliteManager) RelevanceOrder(table string, field string, query string) string {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return ""
+	}
+	for i, w := range words {
+		words[i] = `"` + strings.ReplaceAll(w, `"`, `""`) + `"`
+	}
+	q, _ := m.MakeValue(strings.Join(words, " OR "))
+	fts := m.IdentityString(table + "_fts")
+	return fmt.Sprintf("COALESCE((SELECT rank FROM %s WHERE %s.\"ID\" = %s.\"ID\" AND %s MATCH %s), 0) ASC", fts, fts, m.IdentityString(table), m.IdentityString(field), q)
+}
+
 // BuildQuery combines WHERE, ORDER BY, LIMIT, and OFFSET clauses into a complete query string.
 func (m *SqliteManager) BuildQuery(where string, order string, limit string, offset string) string {
 	res := ""
@@ -88,22 +140,67 @@ func (m *SqliteManager) TableExistsQuery(name string) string {
 }
 
 // Operators returns a list of SQLite compatible operator formats for query building.
-// These formats include comparison, LIKE, IN, BETWEEN, and NULL check operators.
+// These formats include comparison, LIKE, IN, BETWEEN, NULL check and case-insensitive operators.
 func (m *SqliteManager) Operators() []string {
 	return []string{
-		"\"%s\" = %s",                  // Equal
-		"\"%s\" > %s",                  // Greater than
-		"\"%s\" < %s",                  // Less than
-		"\"%s\" LIKE %s",               // Pattern matching
-		"\"%s\" IN (%s)",               // In list
-		"\"%s\" BETWEEN %s AND %s",     // Between range
-		"\"%s\" IS NULL",               // Is null check
-		"\"%s\" <> %s",                 // Not equal
-		"\"%s\" <= %s",                 // Less than or equal
-		"\"%s\" >= %s",                 // Greater than or equal
-		"\"%s\" NOT LIKE %s",           // Not like pattern
-		"\"%s\" NOT IN (%s)",           // Not in list
-		"\"%s\" NOT BETWEEN %s AND %s", // Not between range
-		"\"%s\" IS NOT NULL",           // Is not null check
+		"\"%s\" = %s",                              // Equal
+		"\"%s\" > %s",                              // Greater than
+		"\"%s\" < %s",                              // Less than
+		"\"%s\" LIKE %s",                           // Pattern matching
+		"\"%s\" IN (%s)",                           // In list
+		"\"%s\" BETWEEN %s AND %s",                 // Between range
+		"\"%s\" IS NULL",                           // Is null check
+		"\"%s\" LIKE %s ESCAPE '\\'",               // Escaped pattern matching
+		"LOWER(\"%s\") = LOWER(%s)",                // Case-insensitive equal
+		"LOWER(\"%s\") LIKE LOWER(%s) ESCAPE '\\'", // Case-insensitive pattern matching
+		"\"ID\" IN (SELECT \"ID\" FROM \"{table}_fts\" WHERE \"%s\" MATCH '\"' || REPLACE(REPLACE(%s, '\"', '\"\"'), ' ', '\" OR \"') || '\"')", // Full-text search
+		"\"%s\" <> %s",                                 // Not equal
+		"\"%s\" <= %s",                                 // Less than or equal
+		"\"%s\" >= %s",                                 // Greater than or equal
+		"\"%s\" NOT LIKE %s",                           // Not like pattern
+		"\"%s\" NOT IN (%s)",                           // Not in list
+		"\"%s\" NOT BETWEEN %s AND %s",                 // Not between range
+		"\"%s\" IS NOT NULL",                           // Is not null check
+		"\"%s\" NOT LIKE %s ESCAPE '\\'",               // Not escaped pattern
+		"LOWER(\"%s\") <> LOWER(%s)",                   // Case-insensitive not equal
+		"LOWER(\"%s\") NOT LIKE LOWER(%s) ESCAPE '\\'", // Case-insensitive not like pattern
+		"\"ID\" NOT IN (SELECT \"ID\" FROM \"{table}_fts\" WHERE \"%s\" MATCH '\"' || REPLACE(REPLACE(%s, '\"', '\"\"'), ' ', '\" OR \"') || '\"')", // Not full-text search
+	}
+}
+
+// ColumnType returns the SQLite column definition for a field.
+// SQLite uses type affinities rather than sized types, so sizes and
+// sign are not part of the definition. Computed columns are stored generated columns.
+func (m *SqliteManager) ColumnType(c Column) string {
+	if c.AutoIncrement {
+		return "INTEGER PRIMARY KEY AUTOINCREMENT"
+	}
+	var res string
+	switch c.Type {
+	case tInt, tLong, tBool:
+		res = "INTEGER"
+	case tFloat, tDouble:
+		res = "REAL"
+	case tDecimal:
+		res = "NUMERIC"
+	default:
+		res = "TEXT"
+	}
+	if c.Computed != "" {
+		return fmt.Sprintf("%s GENERATED ALWAYS AS (%s) STORED", res, c.Computed)
 	}
+	if !c.AllowNull {
+		res += " NOT NULL"
+	}
+	return res
+}
+
+// MakeValue converts a value to a SQLite literal.
+func (m *SqliteManager) MakeValue(value interface{}) (string, bool) {
+	return utils.MakeValue(value)
+}
+
+// InsertReturning generates an INSERT statement with a RETURNING clause for the key column.
+func (m *SqliteManager) InsertReturning(table string, fields string, values string, key string) (string, bool) {
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES(%s) RETURNING %s", table, fields, values, key), true
 }
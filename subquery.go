@@ -0,0 +1,138 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+
+// Package mud provides subqueries against the tables of other models.
+package mud
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/markoxley/mud/where"
+)
+
+// subquery selects a field of the models that match the criteria
+type subquery struct {
+	m     Modeller
+	table string
+	field string
+	c     *Criteria
+	// scoped indicates the default scope and tenant of the model have been
+	// applied to the criteria by the query that contains the subquery
+	scoped bool
+	// err is the error in the criteria, which is returned by the query that
+	// contains the subquery
+	err error
+}
+
+// Subquery returns a query that selects the field of the models of type T
+// that match the criteria, for use with where.InQuery and where.Exists:
+//
+//	where.InQuery("CustomerID", mud.Subquery[Customer]("ID", where.Equal("Country", "UK")))
+//
+// The criteria are given in any form accepted by Fetch. Removed models are
+// excluded unless IncDeleted is set, and the default scope and tenant of T
+// are applied by the database handle that runs the query, in the same way
+// as for Fetch. A subquery of a model with a tenant field cannot be rendered
+// outside of a query. When the field is empty the query selects 1, which is
+// enough for where.Exists. Columns of the outer query are referred to with
+// where.Col and the name of their table, as in where.Col("Order.CustomerID").
+// Queries that contain a subquery with invalid criteria, or a condition that
+// cannot be rendered, return an error rather than dropping the condition
+// @param field
+// @param criteria
+// @return where.Query
+func Subquery[T Modeller](field string, criteria ...interface{}) where.Query {
+	m := *new(T)
+	t := GetTableName(m)
+	c, err := criteriaOf(criteria)
+	if err != nil {
+		return subquery{m: m, table: t, field: field, c: &Criteria{}, err: err}
+	}
+	sc := *c
+	sc.table = t
+	return subquery{m: m, table: t, field: field, c: &sc}
+}
+
+// Select returns the SQL of the subquery. The order and limit of the criteria
+// are not used
+// @param operators
+// @param quote
+// @param value
+// @return string
+func (q subquery) Select(operators []string, quote func(string) string, value func(interface{}) (string, bool)) string {
+	if q.err != nil {
+		return ""
+	}
+	if _, ok := tenantField(getDefs(q.m, true)); ok && !q.scoped {
+		// The rows of other tenants must not be visible to the outer query
+		return ""
+	}
+	col := "1"
+	if q.field != "" {
+		col = quote(q.field)
+	}
+	res := fmt.Sprintf("SELECT %s FROM %s", col, quote(q.table))
+	if q.c.Where != nil && q.c.renderCondition(operators, value) == "" {
+		// An invalid condition must not select every row
+		return ""
+	}
+	if wh := strings.TrimSpace(q.c.whereSQL(operators, quote, value)); wh != "" {
+		res += " " + wh
+	}
+	return res
+}
+
+// scopeQueries returns a copy of the condition with the default scope and
+// tenant applied to the criteria of each subquery it contains, and whether
+// it contains any subqueries. An error is returned for a subquery with invalid
+// criteria or a condition that cannot be rendered
+// @param w
+// @return interface{}
+// @return bool
+// @return error
+func (db *DB) scopeQueries(w interface{}) (interface{}, bool, error) {
+	var b *where.Builder
+	switch v := w.(type) {
+	case *where.Builder:
+		b = v
+	case where.Builder:
+		b = &v
+	default:
+		return w, false, nil
+	}
+	var err error
+	found := false
+	res := b.MapQueries(func(q where.Query) where.Query {
+		found = true
+		s, ok := q.(subquery)
+		if !ok || s.scoped || err != nil {
+			return q
+		}
+		if s.err != nil {
+			err = s.err
+			return q
+		}
+		flds, _, e := db.tableTest(s.m)
+		if e != nil {
+			err = e
+			return q
+		}
+		if s.c, e = db.scope(s.m, flds, s.c); e != nil {
+			err = e
+			return q
+		}
+		// Dropping the subquery would leave the containing query unfiltered
+		if s.c.invalidWhere(db.mgr) {
+			err = ErrInvalidCondition
+			return q
+		}
+		s.scoped = true
+		return s
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return res, found, nil
+}
@@ -0,0 +1,259 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+
+// Package mud provides separation of the data of tenants.
+package mud
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/markoxley/mud/where"
+)
+
+// TenancyMode specifies how the data of tenants is separated.
+type TenancyMode string
+
+// Tenancy modes
+const (
+	// TenancyColumn keeps the rows of all tenants in the same tables. Models with
+	// a field tagged tenant only ever read and write the rows of the current tenant
+	TenancyColumn TenancyMode = ""
+	// TenancyDatabase gives each tenant its own database, or schema, named by
+	// Config.TenantDatabase
+	TenancyDatabase TenancyMode = "database"
+)
+
+// ErrNoTenant is returned when a model has a tenant field, but neither the
+// handle nor its context specifies a tenant.
+var ErrNoTenant = errors.New("no tenant")
+
+// ErrCrossTenant is returned when a model that belongs to another tenant is
+// saved or removed.
+var ErrCrossTenant = errors.New("model belongs to another tenant")
+
+// ErrInvalidTenant is returned when a tenant ID is empty, or cannot be used
+// in the name of a database.
+var ErrInvalidTenant = errors.New("invalid tenant")
+
+// tenantName matches the tenant IDs that can be used in database names
+var tenantName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tenantKey is the context key of the tenant
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx carrying the tenant ID.
+// Handles using the context, through DB.WithContext, act for the tenant.
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// TenantFromContext returns the tenant ID carried by ctx
+func TenantFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(tenantKey{}).(string)
+	return id, ok && id != ""
+}
+
+// tenantPool holds the databases of the tenants when each has its own database
+type tenantPool struct {
+	mu  sync.Mutex
+	dbs map[string]*DB
+}
+
+// ForTenant returns a handle to the database that acts for the tenant.
+// With TenancyColumn the handle shares the connection pool of db. With
+// TenancyDatabase the handle is connected to the database of the tenant,
+// which is opened on first use and closed with db.
+// @param id
+// @return *DB
+// @return error
+func (db *DB) ForTenant(id string) (*DB, error) {
+	if id == "" {
+		return nil, ErrInvalidTenant
+	}
+	if db.cfg.Tenancy != TenancyDatabase {
+		c := *db
+		c.tenant = id
+		c.derived = true
+		return &c, nil
+	}
+	if !tenantName.MatchString(id) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTenant, id)
+	}
+	if db.tenant == id {
+		return db, nil
+	}
+
+	db.tenants.mu.Lock()
+	defer db.tenants.mu.Unlock()
+	t, ok := db.tenants.dbs[id]
+	if !ok {
+		var err error
+		if t, err = db.openTenant(id); err != nil {
+			return nil, err
+		}
+		db.tenants.dbs[id] = t
+	}
+	c := *t
+	c.ctx = db.ctx
+	c.primary = db.primary
+	c.derived = true
+	return &c, nil
+}
+
+// openTenant connects to the database of the tenant
+// @param id
+// @return *DB
+// @return error
+func (db *DB) openTenant(id string) (*DB, error) {
+	cfg := *db.cfg
+	cfg.Database = strings.ReplaceAll(cfg.TenantDatabase, "{tenant}", id)
+	cfg.Tenancy = TenancyColumn
+	cfg.TenantDatabase = ""
+	cfg.Replicas = make([]Config, 0, len(db.cfg.Replicas))
+	for _, r := range db.cfg.Replicas {
+		r.Database = strings.ReplaceAll(r.Database, "{tenant}", id)
+		cfg.Replicas = append(cfg.Replicas, r)
+	}
+	t, err := New(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	t.tenant = id
+	t.logger = db.logger
+	t.instrumenters = db.instrumenters
+	t.balancer = db.balancer
+	return t, nil
+}
+
+// closeTenants disconnects from the databases of the tenants
+func (db *DB) closeTenants() {
+	if db.tenants == nil {
+		return
+	}
+	db.tenants.mu.Lock()
+	defer db.tenants.mu.Unlock()
+	for id, t := range db.tenants.dbs {
+		t.Close()
+		delete(db.tenants.dbs, id)
+	}
+}
+
+// currentTenant returns the tenant the handle acts for, from the handle or its context
+// @return string
+// @return bool
+func (db *DB) currentTenant() (string, bool) {
+	if db.tenant != "" {
+		return db.tenant, true
+	}
+	return TenantFromContext(db.context())
+}
+
+// tenantField returns the field of the model that holds its tenant
+// @param flds
+// @return field
+// @return bool
+func tenantField(flds []field) (field, bool) {
+	for _, f := range flds {
+		if f.tenant {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// tenantCondition returns the condition restricting a query to the rows of the
+// current tenant, or an empty string if the model has no tenant field
+// @param flds
+// @return string
+// @return error
+func (db *DB) tenantCondition(flds []field) (string, error) {
+	f, ok := tenantField(flds)
+	if !ok {
+		return "", nil
+	}
+	id, ok := db.currentTenant()
+	if !ok {
+		return "", ErrNoTenant
+	}
+	vl, _ := db.mgr.MakeValue(id)
+	return fmt.Sprintf("%s = %s", db.mgr.IdentityString(f.name), vl), nil
+}
+
+// tenantScope returns the criteria restricted to the rows of the current tenant
+// @param flds
+// @param c
+// @return *Criteria
+// @return error
+func (db *DB) tenantScope(flds []field, c *Criteria) (*Criteria, error) {
+	if c == nil {
+		c = &Criteria{}
+	}
+	cond, err := db.tenantCondition(flds)
+	if err != nil || cond == "" {
+		return c, err
+	}
+	return c.withWhere(cond), nil
+}
+
+// tenantWritten checks that a write of a single model, which affected the
+// number of rows given, did not miss the row of the model because the row
+// belongs to another tenant. Writes are filtered by tenant, so the tenant of
+// a model that has been changed in memory matches nothing
+// @param m
+// @param flds
+// @param rows
+// @param tx
+// @return error
+func (db *DB) tenantWritten(m Modeller, flds []field, rows int64, tx ...*sql.Tx) error {
+	if _, ok := tenantField(flds); !ok || rows != 0 {
+		return nil
+	}
+	// Nothing is affected when a removed model is removed again, or when MySQL
+	// finds no change, so only a row that is not the tenant's is an error
+	c := Criteria{Where: where.Equal("ID", *m.GetID()), IncDeleted: true}
+	n, err := db.UsePrimary().count(m, c.Unscoped(), tx...)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrCrossTenant
+	}
+	return nil
+}
+
+// tenantModel checks that the model belongs to the current tenant. New models
+// without a tenant are stamped with the current tenant
+// @param m
+// @param flds
+// @return error
+func (db *DB) tenantModel(m Modeller, flds []field) error {
+	f, ok := tenantField(flds)
+	if !ok {
+		return nil
+	}
+	id, ok := db.currentTenant()
+	if !ok {
+		return ErrNoTenant
+	}
+	v := reflect.ValueOf(m).Elem()
+	vl, ok := f.get(v)
+	if !ok || reflect.ValueOf(vl).IsZero() {
+		if !m.IsNew() {
+			return ErrCrossTenant
+		}
+		setFieldValue(f.value(v, true), f, id)
+		return nil
+	}
+	if fmt.Sprint(vl) != id {
+		return ErrCrossTenant
+	}
+	return nil
+}
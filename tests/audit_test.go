@@ -0,0 +1,138 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+package tests
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/markoxley/mud"
+	"github.com/markoxley/mud/utils"
+	"github.com/markoxley/mud/where"
+	"github.com/stretchr/testify/assert"
+)
+
+// newAuditDB creates a SQLite database that records an audit trail
+func newAuditDB(t *testing.T, deletable bool) *mud.DB {
+	db, err := mud.New(&mud.Config{
+		Type:      "sqlite",
+		Database:  filepath.Join(t.TempDir(), "mud_test.db"),
+		Audit:     true,
+		Deletable: deletable,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	return db
+}
+
+// kinds returns the kinds of the audit entries
+func kinds(es []*mud.AuditEntry) []string {
+	res := make([]string, 0, len(es))
+	for _, e := range es {
+		res = append(res, e.Kind)
+	}
+	return res
+}
+
+func TestAuditSave(t *testing.T) {
+	db := newAuditDB(t, false)
+	actor := db.WithContext(mud.WithActor(context.Background(), "alice"))
+
+	m := &TestModel{Name: "Ann", Age: 30}
+	assert.NoError(t, actor.Save(m))
+	m.Age = 31
+	assert.NoError(t, actor.Save(m))
+	// Saving without changes is not recorded
+	assert.NoError(t, db.Save(m))
+	assert.NoError(t, db.Remove(m))
+
+	es, err := db.History(m)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{mud.AuditInsert, mud.AuditUpdate, mud.AuditDisable}, kinds(es))
+	for _, e := range es {
+		assert.Equal(t, "TestModel", e.Entity)
+		assert.Equal(t, *m.ID, e.EntityID)
+	}
+	assert.Equal(t, "alice", es[0].Actor)
+	assert.Equal(t, "", es[2].Actor)
+
+	tests := []struct {
+		name    string
+		entry   int
+		column  string
+		wantOld *string
+		wantNew *string
+	}{
+		{name: "inserted name", entry: 0, column: "Name", wantNew: utils.Ptr("Ann")},
+		{name: "inserted age", entry: 0, column: "Age", wantNew: utils.Ptr("30")},
+		{name: "updated age", entry: 1, column: "Age", wantOld: utils.Ptr("30"), wantNew: utils.Ptr("31")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := es[tt.entry].Diff()
+			assert.NoError(t, err)
+			assert.Equal(t, mud.AuditChange{Old: tt.wantOld, New: tt.wantNew}, d[tt.column])
+		})
+	}
+
+	d, err := es[1].Diff()
+	assert.NoError(t, err)
+	assert.Len(t, d, 1)
+	d, err = es[2].Diff()
+	assert.NoError(t, err)
+	assert.Contains(t, d, "DeleteDate")
+	assert.Nil(t, d["DeleteDate"].Old)
+	assert.NotNil(t, d["DeleteDate"].New)
+}
+
+func TestAuditRemoveMany(t *testing.T) {
+	db := newAuditDB(t, true)
+	models := []*TestModel{{Name: "a", Age: 1}, {Name: "b", Age: 2}, {Name: "c", Age: 2}}
+	for _, m := range models {
+		assert.NoError(t, db.Save(m))
+	}
+	n, err := db.RemoveMany(&TestModel{}, &mud.Criteria{Where: where.Equal("Age", 2)})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 1, db.Count(&TestModel{}, mud.Criteria{IncDeleted: true}))
+
+	for i, want := range [][]string{
+		{mud.AuditInsert},
+		{mud.AuditInsert, mud.AuditDelete},
+		{mud.AuditInsert, mud.AuditDelete},
+	} {
+		es, err := db.History(models[i])
+		assert.NoError(t, err)
+		assert.Equal(t, want, kinds(es))
+	}
+	es, err := db.History(models[1])
+	assert.NoError(t, err)
+	d, err := es[1].Diff()
+	assert.NoError(t, err)
+	assert.Equal(t, mud.AuditChange{Old: utils.Ptr("b")}, d["Name"])
+}
+
+func TestAuditRollback(t *testing.T) {
+	db := newAuditDB(t, false)
+	m := &TestModel{Name: "x"}
+	assert.NoError(t, db.Save(m))
+
+	// A failing change leaves neither the change nor its audit entry
+	tx, err := db.BeginTransaction()
+	assert.NoError(t, err)
+	m.Name = "y"
+	assert.NoError(t, db.Save(m, tx))
+	assert.NoError(t, db.RollbackTransaction(tx))
+
+	es, err := db.History(m)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{mud.AuditInsert}, kinds(es))
+
+	assert.True(t, errors.Is(db.Save(&TenantModel{Name: "t"}), mud.ErrNoTenant))
+	assert.Equal(t, 1, db.Count(&mud.AuditEntry{}))
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/markoxley/mud"
+	"github.com/markoxley/mud/order"
+	"github.com/markoxley/mud/where"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInBatches(t *testing.T) {
+	db, err := mud.New(&mud.Config{
+		Type:         "sqlite",
+		Database:     filepath.Join(t.TempDir(), "mud_test.db"),
+		CursorSecret: "batches",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, db.Save(&TestModel{Name: fmt.Sprintf("user %d", i), Age: i}))
+	}
+	all, err := mud.Fetch[TestModel](db)
+	assert.NoError(t, err)
+	sort.Slice(all, func(i, j int) bool { return *all[i].ID < *all[j].ID })
+	want := make([]string, 0, len(all))
+	for _, m := range all {
+		want = append(want, *m.ID)
+	}
+
+	tests := []struct {
+		name     string
+		size     int
+		criteria interface{}
+		opts     []mud.BatchOptions
+		want     []int
+	}{
+		{name: "exact batches", size: 5, want: []int{5, 5}},
+		{name: "partial batch", size: 4, want: []int{4, 4, 2}},
+		{name: "single batch", size: 20, want: []int{10}},
+		{name: "criteria", size: 2, criteria: where.Less("Age", 5), want: []int{2, 2, 1}},
+		{name: "no rows", size: 2, criteria: where.Greater("Age", 100), want: []int{}},
+		{name: "order", size: 3, opts: []mud.BatchOptions{{Order: order.Desc("Age")}}, want: []int{3, 3, 3, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sizes := []int{}
+			got := []*TestModel{}
+			err := mud.InBatches(db, tt.size, tt.criteria, func(batch []*TestModel) error {
+				sizes = append(sizes, len(batch))
+				got = append(got, batch...)
+				return nil
+			}, tt.opts...)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, sizes)
+			if tt.criteria == nil && tt.opts == nil {
+				ids := make([]string, 0, len(got))
+				for _, m := range got {
+					ids = append(ids, *m.ID)
+				}
+				assert.Equal(t, want, ids)
+			}
+			if tt.opts != nil {
+				for i := 1; i < len(got); i++ {
+					assert.Greater(t, got[i-1].Age, got[i].Age)
+				}
+			}
+		})
+	}
+
+	t.Run("modify between batches", func(t *testing.T) {
+		err := mud.InBatches(db, 3, nil, func(batch []*TestModel) error {
+			for _, m := range batch {
+				m.Age += 100
+				if err := db.Save(m); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 10, db.Count(&TestModel{}, where.NotLess("Age", 100)))
+	})
+
+	t.Run("stop and resume", func(t *testing.T) {
+		stop := errors.New("stop")
+		checkpoint := ""
+		seen := []string{}
+		err := mud.InBatches(db, 3, nil, func(batch []*TestModel) error {
+			if len(seen) == 6 {
+				return stop
+			}
+			for _, m := range batch {
+				seen = append(seen, *m.ID)
+			}
+			return nil
+		}, mud.BatchOptions{Checkpoint: func(cp string) error {
+			checkpoint = cp
+			return nil
+		}})
+		assert.ErrorIs(t, err, stop)
+		assert.NotEmpty(t, checkpoint)
+
+		err = mud.InBatches(db, 3, nil, func(batch []*TestModel) error {
+			for _, m := range batch {
+				seen = append(seen, *m.ID)
+			}
+			return nil
+		}, mud.BatchOptions{Resume: checkpoint})
+		assert.NoError(t, err)
+		assert.Equal(t, want, seen)
+	})
+
+	t.Run("invalid checkpoint", func(t *testing.T) {
+		err := mud.InBatches(db, 3, nil, func([]*TestModel) error { return nil }, mud.BatchOptions{Resume: "bogus"})
+		assert.ErrorIs(t, err, mud.ErrInvalidCursor)
+	})
+
+	t.Run("no cursor secret", func(t *testing.T) {
+		noSecret := newSQLiteDB(t)
+		fn := func([]*TestModel) error { return nil }
+		assert.ErrorIs(t, mud.InBatches(noSecret, 3, nil, fn, mud.BatchOptions{Resume: "x"}), mud.ErrNoCursorSecret)
+		assert.ErrorIs(t, mud.InBatches(noSecret, 3, nil, fn, mud.BatchOptions{Checkpoint: func(string) error { return nil }}), mud.ErrNoCursorSecret)
+		assert.NoError(t, mud.InBatches(noSecret, 3, nil, fn))
+	})
+
+	t.Run("invalid size", func(t *testing.T) {
+		assert.Error(t, mud.InBatches(db, 0, nil, func([]*TestModel) error { return nil }))
+	})
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markoxley/mud"
+	"github.com/markoxley/mud/where"
+	"github.com/stretchr/testify/assert"
+)
+
+// CachedModel is a test model with a cache TTL
+type CachedModel struct {
+	mud.Model
+	Code string `mud:"size:8"`
+}
+
+func (CachedModel) CacheTTL() time.Duration { return 50 * time.Millisecond }
+
+// countSelects returns a function reporting the number of SELECT statements run on db
+func countSelects(db *mud.DB) func() int {
+	n := 0
+	db.SetLogger(mud.LoggerFunc(func(ctx context.Context, e mud.QueryEvent) {
+		if strings.HasPrefix(e.Statement, "SELECT * FROM") {
+			n++
+		}
+	}))
+	return func() int { return n }
+}
+
+func TestQueryCache(t *testing.T) {
+	db := newSQLiteDB(t)
+	assert.NoError(t, db.Save(&TestModel{Name: "cached", Age: 1}))
+	selects := countSelects(db)
+	cached := &mud.Criteria{Where: where.Equal("Name", "cached"), Cache: true}
+
+	first, err := mud.Fetch[TestModel](db, cached)
+	assert.NoError(t, err)
+	assert.Len(t, first, 1)
+	second, err := mud.Fetch[TestModel](db, cached)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, selects(), "second fetch should be served from the cache")
+	assert.Equal(t, first, second)
+
+	// Cached results are copies
+	second[0].Age = 99
+	third, _ := mud.Fetch[TestModel](db, cached)
+	assert.Equal(t, 1, third[0].Age)
+
+	// Queries without the cache option always hit the database
+	_, err = mud.Fetch[TestModel](db, where.Equal("Name", "cached"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, selects())
+
+	// Saving invalidates the table
+	m := first[0]
+	m.Age = 2
+	assert.NoError(t, db.Save(m))
+	res, _ := mud.Fetch[TestModel](db, cached)
+	assert.Equal(t, 3, selects())
+	assert.Equal(t, 2, res[0].Age)
+
+	// Raw statements mentioning the table invalidate it
+	assert.NoError(t, db.RawExecute(`UPDATE "TestModel" SET "Age" = 3`))
+	res, _ = mud.Fetch[TestModel](db, cached)
+	assert.Equal(t, 4, selects())
+	assert.Equal(t, 3, res[0].Age)
+
+	// Removing invalidates the table
+	assert.NoError(t, db.Remove(m))
+	res, _ = mud.Fetch[TestModel](db, cached)
+	assert.Equal(t, 5, selects())
+	assert.Len(t, res, 0)
+
+	// Caching can be disabled
+	db.SetCache(nil)
+	mud.Fetch[TestModel](db, cached)
+	mud.Fetch[TestModel](db, cached)
+	assert.Equal(t, 7, selects())
+}
+
+func TestQueryCacheTTL(t *testing.T) {
+	db := newSQLiteDB(t)
+	assert.NoError(t, db.Save(&CachedModel{Code: "GB"}))
+	selects := countSelects(db)
+	cached := mud.Criteria{Cache: true}
+
+	_, err := mud.First[CachedModel](db, cached)
+	assert.NoError(t, err)
+	_, err = mud.First[CachedModel](db, cached)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, selects())
+
+	// The model TTL expires the entry
+	time.Sleep(60 * time.Millisecond)
+	_, err = mud.First[CachedModel](db, cached)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, selects())
+
+	// The query TTL takes precedence
+	long := mud.Criteria{Cache: true, CacheTTL: time.Hour, Where: where.Equal("Code", "GB")}
+	mud.Fetch[CachedModel](db, long)
+	time.Sleep(60 * time.Millisecond)
+	mud.Fetch[CachedModel](db, long)
+	assert.Equal(t, 3, selects())
+}
+
+func TestLRUCache(t *testing.T) {
+	c := mud.NewLRUCache(2)
+	e := &mud.CacheEntry{Columns: []string{"ID"}}
+
+	c.Set("a", "a1", e, 0)
+	c.Set("a", "a2", e, 0)
+	_, ok := c.Get("a1")
+	assert.True(t, ok)
+
+	// a2 is the least recently used
+	c.Set("b", "b1", e, 0)
+	assert.Equal(t, 2, c.Len())
+	_, ok = c.Get("a2")
+	assert.False(t, ok)
+
+	c.Invalidate("a")
+	_, ok = c.Get("a1")
+	assert.False(t, ok)
+	_, ok = c.Get("b1")
+	assert.True(t, ok)
+
+	c.Set("b", "b2", e, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	_, ok = c.Get("b2")
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestQueryCacheTransaction(t *testing.T) {
+	db := newSQLiteDB(t)
+	m := &TestModel{Name: "tx", Age: 1}
+	assert.NoError(t, db.Save(m))
+	cached := &mud.Criteria{Where: where.Equal("Name", "tx"), Cache: true}
+
+	tx, err := db.BeginTransaction()
+	assert.NoError(t, err)
+	m.Age = 2
+	assert.NoError(t, db.Save(m, tx))
+
+	// Reads outside of the transaction see the old rows, which are not cached
+	res, err := mud.Fetch[TestModel](db, cached)
+	assert.NoError(t, err)
+	if assert.Len(t, res, 1) {
+		assert.Equal(t, 1, res[0].Age)
+	}
+	assert.NoError(t, db.CommitTransaction(tx))
+
+	res, err = mud.Fetch[TestModel](db, cached)
+	assert.NoError(t, err)
+	if assert.Len(t, res, 1) {
+		assert.Equal(t, 2, res[0].Age)
+	}
+
+	// Once the transaction has ended the table is cached again
+	selects := countSelects(db)
+	_, err = mud.Fetch[TestModel](db, cached)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, selects())
+}
+
+// selectHook is an instrumenter that calls fn once, when the next SELECT starts
+type selectHook struct {
+	fn func()
+}
+
+func (h *selectHook) Start(ctx context.Context, op mud.Operation) context.Context {
+	if fn := h.fn; fn != nil && op.Name == mud.OpSelect {
+		h.fn = nil
+		fn()
+	}
+	return ctx
+}
+
+func (h *selectHook) End(context.Context, mud.Operation, mud.OperationResult) {}
+
+func TestQueryCacheInvalidatedDuringQuery(t *testing.T) {
+	db := newSQLiteDB(t)
+	m := &TestModel{Name: "race", Age: 1}
+	assert.NoError(t, db.Save(m))
+	cached := &mud.Criteria{Where: where.Equal("Name", "race"), Cache: true}
+
+	// A write that invalidates the table while the query runs may not be seen
+	// by the query, so its results are not cached
+	db.SetInstrumenters(&selectHook{fn: func() {
+		m.Age = 2
+		assert.NoError(t, db.Save(m))
+	}})
+	_, err := mud.Fetch[TestModel](db, cached)
+	assert.NoError(t, err)
+
+	selects := countSelects(db)
+	res, err := mud.Fetch[TestModel](db, cached)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, selects())
+	if assert.Len(t, res, 1) {
+		assert.Equal(t, 2, res[0].Age)
+	}
+}
+
+func TestQueryCacheSubquery(t *testing.T) {
+	db := newSQLiteDB(t)
+	c := &Customer{Name: "Ann", Country: "UK"}
+	assert.NoError(t, db.Save(c))
+	assert.NoError(t, db.Save(&Purchase{CustomerID: *c.ID, Amount: 1}))
+	cached := &mud.Criteria{Where: where.InQuery("CustomerID", mud.Subquery[Customer]("ID", where.Equal("Country", "UK"))), Cache: true}
+
+	ps, err := mud.Fetch[Purchase](db, cached)
+	assert.NoError(t, err)
+	assert.Len(t, ps, 1)
+
+	// Writes to the table of the subquery are seen
+	c.Country = "US"
+	assert.NoError(t, db.Save(c))
+	ps, err = mud.Fetch[Purchase](db, cached)
+	assert.NoError(t, err)
+	assert.Empty(t, ps)
+}
@@ -31,6 +31,7 @@ import (
 	"testing"
 
 	"github.com/markoxley/mud"
+	"github.com/markoxley/mud/utils"
 	"github.com/markoxley/mud/where"
 )
 
@@ -53,20 +54,28 @@ func (m *mockManager) ConnectionString(cfg *mud.Config) (string, error) {
 
 func (m *mockManager) Operators() []string {
 	return []string{
-		"%s = %s",                  // Equal
-		"%s > %s",                  // Greater than
-		"%s < %s",                  // Less than
-		"%s LIKE %s",               // Pattern matching
-		"%s IN (%s)",               // In list
-		"%s BETWEEN %s AND %s",     // Between range
-		"%s IS NULL",               // Is null check
-		"%s <> %s",                 // Not equal
-		"%s <= %s",                 // Less than or equal
-		"%s >= %s",                 // Greater than or equal
-		"%s NOT LIKE %s",           // Not like pattern
-		"%s NOT IN (%s)",           // Not in list
-		"%s NOT BETWEEN %s AND %s", // Not between range
-		"%s IS NOT NULL",           // Is not null check
+		"%s = %s",                              // Equal
+		"%s > %s",                              // Greater than
+		"%s < %s",                              // Less than
+		"%s LIKE %s",                           // Pattern matching
+		"%s IN (%s)",                           // In list
+		"%s BETWEEN %s AND %s",                 // Between range
+		"%s IS NULL",                           // Is null check
+		"%s LIKE %s ESCAPE '\\'",               // Escaped pattern matching
+		"LOWER(%s) = LOWER(%s)",                // Case-insensitive equal
+		"LOWER(%s) LIKE LOWER(%s) ESCAPE '\\'", // Case-insensitive pattern matching
+		"MATCH(%s, %s)",                        // Full-text search
+		"%s <> %s",                             // Not equal
+		"%s <= %s",                             // Less than or equal
+		"%s >= %s",                             // Greater than or equal
+		"%s NOT LIKE %s",                       // Not like pattern
+		"%s NOT IN (%s)",                       // Not in list
+		"%s NOT BETWEEN %s AND %s",             // Not between range
+		"%s IS NOT NULL",                       // Is not null check
+		"%s NOT LIKE %s ESCAPE '\\'",           // Not escaped pattern
+		"LOWER(%s) <> LOWER(%s)",               // Case-insensitive not equal
+		"LOWER(%s) NOT LIKE LOWER(%s) ESCAPE '\\'", // Case-insensitive not like pattern
+		"NOT MATCH(%s, %s)",                        // Not full-text search
 	}
 }
 
@@ -117,6 +126,26 @@ func (m *mockManager) IndexCreate() string {
 	return "CREATE INDEX"
 }
 
+func (m *mockManager) FullTextCreate(table string, fields []string) []string {
+	return nil
+}
+
+func (m *mockManager) RelevanceOrder(table string, field string, query string) string {
+	return fmt.Sprintf("RELEVANCE(%s, '%s') DESC", field, query)
+}
+
+func (m *mockManager) ColumnType(c mud.Column) string {
+	return c.Type.String()
+}
+
+func (m *mockManager) MakeValue(value interface{}) (string, bool) {
+	return utils.MakeValue(value)
+}
+
+func (m *mockManager) InsertReturning(table string, fields string, values string, key string) (string, bool) {
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES(%s)", table, fields, values), false
+}
+
 func TestCriteriaWhereString(t *testing.T) {
 	mgr := &mockManager{}
 
@@ -0,0 +1,138 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+package tests
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/markoxley/mud"
+	"github.com/markoxley/mud/order"
+	"github.com/markoxley/mud/where"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginateCursor(t *testing.T) {
+	db := newSQLiteDB(t)
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, db.Save(&TestModel{Name: fmt.Sprintf("user %d", i), Age: i % 4}))
+	}
+	all, err := mud.Fetch[TestModel](db)
+	assert.NoError(t, err)
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Age != all[j].Age {
+			return all[i].Age > all[j].Age
+		}
+		return *all[i].ID < *all[j].ID
+	})
+	want := make([]string, 0, len(all))
+	for _, m := range all {
+		want = append(want, *m.ID)
+	}
+	ids := func(p *mud.CursorPage[TestModel]) []string {
+		res := make([]string, 0, len(p.Items))
+		for _, m := range p.Items {
+			res = append(res, *m.ID)
+		}
+		return res
+	}
+
+	// Forward through every page
+	pages := make([]*mud.CursorPage[TestModel], 0)
+	got := make([]string, 0)
+	cur := ""
+	for {
+		p, err := mud.PaginateCursor[TestModel](db, cur, 3, order.Desc("Age"))
+		assert.NoError(t, err)
+		pages = append(pages, p)
+		got = append(got, ids(p)...)
+		if p.Next == "" {
+			break
+		}
+		cur = p.Next
+	}
+	assert.Equal(t, want, got)
+	assert.Len(t, pages, 4)
+	assert.Empty(t, pages[0].Prev)
+	assert.NotEmpty(t, pages[3].Prev)
+
+	// Back from the last page
+	p, err := mud.PaginateCursor[TestModel](db, pages[3].Prev, 3, order.Desc("Age"))
+	assert.NoError(t, err)
+	assert.Equal(t, ids(pages[2]), ids(p))
+	assert.NotEmpty(t, p.Next)
+	p, err = mud.PaginateCursor[TestModel](db, pages[1].Prev, 3, order.Desc("Age"))
+	assert.NoError(t, err)
+	assert.Equal(t, ids(pages[0]), ids(p))
+	assert.Empty(t, p.Prev)
+
+	// Rows inserted before the cursor do not shift the next page
+	assert.NoError(t, db.Save(&TestModel{Name: "late", Age: 9}))
+	p, err = mud.PaginateCursor[TestModel](db, pages[0].Next, 3, order.Desc("Age"))
+	assert.NoError(t, err)
+	assert.Equal(t, ids(pages[1]), ids(p))
+
+	// Criteria are combined with the cursor
+	p, err = mud.PaginateCursor[TestModel](db, "", 10, order.Asc("Name"), where.Equal("Age", 1))
+	assert.NoError(t, err)
+	assert.Len(t, p.Items, 3)
+	assert.Empty(t, p.Next)
+}
+
+func TestPaginateCursorInvalid(t *testing.T) {
+	db := newSQLiteDB(t)
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, db.Save(&TestModel{Name: fmt.Sprintf("user %d", i), Age: i}))
+	}
+	p, err := mud.PaginateCursor[TestModel](db, "", 2, order.Asc("Age"))
+	assert.NoError(t, err)
+
+	tampered := []byte(p.Next)
+	tampered[3] ^= 1
+	_, err = mud.PaginateCursor[TestModel](db, string(tampered), 2, order.Asc("Age"))
+	assert.ErrorIs(t, err, mud.ErrInvalidCursor)
+
+	_, err = mud.PaginateCursor[TestModel](db, "garbage", 2, order.Asc("Age"))
+	assert.ErrorIs(t, err, mud.ErrInvalidCursor)
+
+	// Cursors are bound to their ordering
+	_, err = mud.PaginateCursor[TestModel](db, p.Next, 2, order.Desc("Age"))
+	assert.ErrorIs(t, err, mud.ErrInvalidCursor)
+
+	_, err = mud.PaginateCursor[TestModel](db, "", 2, order.Asc("Missing"))
+	assert.Error(t, err)
+}
+
+func TestPaginateCursorValues(t *testing.T) {
+	db, err := mud.New(&mud.Config{
+		Type:         "sqlite",
+		Database:     filepath.Join(t.TempDir(), "mud_test.db"),
+		CursorSecret: "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, db.Save(&TestModel{Name: fmt.Sprintf("user %d", i), Age: i}))
+	}
+	sign := func(payload string) string {
+		h := hmac.New(sha256.New, []byte("secret"))
+		h.Write([]byte(payload))
+		return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+	}
+
+	// The values of a cursor are parsed as the types of their fields, so a
+	// cursor signed with a leaked secret cannot inject SQL
+	_, err = mud.PaginateCursor[TestModel](db, sign(`{"k":"TestModel:Age+,ID+","v":["0 OR 1=1","x"]}`), 2, order.Asc("Age"))
+	assert.ErrorIs(t, err, mud.ErrInvalidCursor)
+	p, err := mud.PaginateCursor[TestModel](db, sign(`{"k":"TestModel:Name+,ID+","v":["user 1' OR '1'='1","x"]}`), 10, order.Asc("Name"))
+	assert.NoError(t, err)
+	assert.Len(t, p.Items, 2)
+}
@@ -135,6 +135,7 @@ func TestSaveAndFetchSQLite(t *testing.T) {
 	assert.Equal(t, model.ID, fetchedModel.ID)
 	assert.Equal(t, model.Name, fetchedModel.Name)
 	assert.Equal(t, model.Age, fetchedModel.Age)
+	assert.False(t, fetchedModel.CreateDate.IsZero())
 }
 
 func TestSaveAndFetchMSSQL(t *testing.T) {
@@ -0,0 +1,132 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/markoxley/mud"
+	"github.com/stretchr/testify/assert"
+)
+
+// statements records the statements executed by a database
+type statements struct {
+	list []string
+}
+
+func (s *statements) logger() mud.Logger {
+	return mud.LoggerFunc(func(_ context.Context, e mud.QueryEvent) {
+		s.list = append(s.list, e.Statement)
+	})
+}
+
+// updates returns the UPDATE statements recorded
+func (s *statements) updates() []string {
+	res := []string{}
+	for _, st := range s.list {
+		if strings.HasPrefix(st, "UPDATE") {
+			res = append(res, st)
+		}
+	}
+	return res
+}
+
+func TestChanges(t *testing.T) {
+	db := newSQLiteDB(t)
+	m := &TestModel{Name: "Ann", Age: 30}
+	assert.Nil(t, mud.Changes(m))
+	assert.NoError(t, db.Save(m))
+	assert.Empty(t, mud.Changes(m))
+
+	loaded, err := mud.FromID[TestModel](db, *m.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, mud.Changes(loaded))
+	loaded.Age = 31
+	assert.Equal(t, map[string]mud.Change{"Age": {Old: 30, New: 31}}, mud.Changes(loaded))
+	loaded.Age = 30
+	assert.Empty(t, mud.Changes(loaded))
+
+	for r := range mud.Range[TestModel](db) {
+		r.Name = "Bob"
+		assert.Equal(t, map[string]mud.Change{"Name": {Old: "Ann", New: "Bob"}}, mud.Changes(r))
+	}
+}
+
+func TestComparableModel(t *testing.T) {
+	db := newSQLiteDB(t)
+	m := &TestModel{Name: "Ann", Age: 30}
+	assert.NoError(t, db.Save(m))
+
+	// Models with a snapshot can still be compared and used as map keys
+	cp := *m
+	assert.True(t, cp == *m)
+	seen := map[TestModel]bool{*m: true}
+	assert.True(t, seen[cp])
+
+	// Saving a copy does not change the snapshot of the original
+	cp.Age = 31
+	assert.NoError(t, db.Save(&cp))
+	assert.Empty(t, mud.Changes(&cp))
+	assert.Equal(t, map[string]mud.Change{"Age": {Old: 30, New: 31}}, mud.Changes(&TestModel{Model: m.Model, Name: "Ann", Age: 31}))
+}
+
+func TestPartialUpdate(t *testing.T) {
+	db := newSQLiteDB(t)
+	var log statements
+	db.SetLogger(log.logger())
+
+	m := &TestModel{Name: "Ann", Age: 30}
+	assert.NoError(t, db.Save(m))
+
+	t.Run("unchanged", func(t *testing.T) {
+		log.list = nil
+		before := m.LastUpdate
+		assert.NoError(t, db.Save(m))
+		assert.Empty(t, log.updates())
+		assert.Equal(t, before, m.LastUpdate)
+	})
+
+	t.Run("changed columns only", func(t *testing.T) {
+		log.list = nil
+		m.Age = 31
+		assert.NoError(t, db.Save(m))
+		if assert.Len(t, log.updates(), 1) {
+			u := log.updates()[0]
+			assert.Contains(t, u, "Age")
+			assert.Contains(t, u, "LastUpdate")
+			assert.NotContains(t, u, "Name")
+		}
+	})
+
+	t.Run("concurrent edits", func(t *testing.T) {
+		a, err := mud.FromID[TestModel](db, *m.ID)
+		assert.NoError(t, err)
+		b, err := mud.FromID[TestModel](db, *m.ID)
+		assert.NoError(t, err)
+		a.Name = "Alice"
+		b.Age = 40
+		assert.NoError(t, db.Save(a))
+		assert.NoError(t, db.Save(b))
+		got, err := mud.FromID[TestModel](db, *m.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Alice", got.Name)
+		assert.Equal(t, 40, got.Age)
+	})
+
+	t.Run("transaction", func(t *testing.T) {
+		tx, err := db.BeginTransaction()
+		assert.NoError(t, err)
+		m.Name = "Rolled back"
+		assert.NoError(t, db.Save(m, tx))
+		assert.NoError(t, db.RollbackTransaction(tx))
+		// Without a snapshot the next save writes every column
+		assert.Nil(t, mud.Changes(m))
+		assert.NoError(t, db.Save(m))
+		got, err := mud.FromID[TestModel](db, *m.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Rolled back", got.Name)
+	})
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+package tests
+
+import (
+	"testing"
+
+	"github.com/markoxley/mud"
+	"github.com/markoxley/mud/order"
+	"github.com/markoxley/mud/where"
+	"github.com/stretchr/testify/assert"
+)
+
+// Article is a test model with a full-text searched field
+type Article struct {
+	mud.Model
+	Title string `mud:"size:64"`
+	Body  string `mud:"fulltext,size:1000"`
+}
+
+func TestMatchString(t *testing.T) {
+	tests := []struct {
+		mgr  mud.Manager
+		name string
+		in   *where.Builder
+		out  string
+	}{
+		{mgr: &mud.MySQLManager{}, name: "MySQL Match", in: where.Match("Body", " quick  fox "), out: "MATCH(`Body`) AGAINST ('quick fox' IN NATURAL LANGUAGE MODE)"},
+		{mgr: &mud.MySQLManager{}, name: "MySQL Not Match", in: where.Equal("Title", "a").AndNotMatch("Body", "fox"), out: "`Title` = 'a' AND NOT MATCH(`Body`) AGAINST ('fox' IN NATURAL LANGUAGE MODE)"},
+		{mgr: &mud.MSSQLManager{}, name: "MSSQL Match", in: where.Match("Body", "it's"), out: "FREETEXT([Body], N'it''s')"},
+		{mgr: &mud.MSSQLManager{}, name: "MSSQL Or Not Match", in: where.Equal("Title", "a").OrNotMatch("Body", "fox"), out: "[Title] = N'a' OR NOT FREETEXT([Body], N'fox')"},
+		{mgr: &mud.SqliteManager{}, name: "SQLite Empty", in: where.Match("Body", "  "), out: "1 = 0"},
+		{mgr: &mud.SqliteManager{}, name: "SQLite Not Empty", in: where.NotMatch("Body", ""), out: "1 = 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.out, tt.in.Render(tt.mgr.Operators(), tt.mgr.MakeValue))
+		})
+	}
+}
+
+func TestRelevanceString(t *testing.T) {
+	ord := order.Relevance("Body", "fox").Asc("Title")
+	tests := []struct {
+		name string
+		mgr  mud.Manager
+		out  string
+	}{
+		{name: "MySQL", mgr: &mud.MySQLManager{}, out: "MATCH(`Body`) AGAINST ('fox' IN NATURAL LANGUAGE MODE) DESC, `Title` asc"},
+		{name: "MSSQL", mgr: &mud.MSSQLManager{}, out: "(SELECT ft.[RANK] FROM FREETEXTTABLE([Article], [Body], N'fox') AS ft WHERE ft.[KEY] = [Article].[ID]) DESC, [Title] asc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ord.RenderWith(tt.mgr.IdentityString, func(field, query string) string {
+				return tt.mgr.RelevanceOrder("Article", field, query)
+			})
+			assert.Equal(t, tt.out, got)
+		})
+	}
+	// Relevance needs the dialect, so it is left out of the plain rendering
+	assert.Equal(t, "\"Title\" asc", ord.Render((&mud.SqliteManager{}).IdentityString))
+	assert.Equal(t, "`Title` asc", ord.String())
+}
+
+func TestFullText(t *testing.T) {
+	db := newSQLiteDB(t)
+	as := []*Article{
+		{Title: "Foxes", Body: "The quick brown fox jumps over the lazy dog"},
+		{Title: "Dogs", Body: "A lazy dog sleeps all day"},
+		{Title: "Cats", Body: "Cats ignore the fox and the dog, the fox and the dog"},
+	}
+	for _, a := range as {
+		assert.NoError(t, db.Save(a))
+	}
+
+	titles := func(criteria ...interface{}) []string {
+		t.Helper()
+		got, err := mud.Fetch[Article](db, criteria...)
+		assert.NoError(t, err)
+		res := make([]string, 0, len(got))
+		for _, a := range got {
+			res = append(res, a.Title)
+		}
+		return res
+	}
+
+	assert.ElementsMatch(t, []string{"Foxes", "Cats"}, titles(where.Match("Body", "fox")))
+	assert.ElementsMatch(t, []string{"Dogs"}, titles(where.NotMatch("Body", "fox")))
+	// Any of the words match, and quotes in the query are harmless
+	assert.ElementsMatch(t, []string{"Dogs", "Cats"}, titles(where.Match("Body", `sleeps "ignore`)))
+	assert.Empty(t, titles(where.Match("Body", "")))
+	assert.Equal(t, 1, db.Count(&Article{}, where.Match("Body", "quick").AndEqual("Title", "Foxes")))
+
+	t.Run("relevance", func(t *testing.T) {
+		got := titles(mud.Criteria{Where: where.Match("Body", "fox"), Order: order.Relevance("Body", "fox")})
+		assert.Equal(t, []string{"Cats", "Foxes"}, got)
+		// Rows that do not match are ordered last
+		got = titles(mud.Criteria{Order: order.Relevance("Body", "fox").Asc("Title")})
+		assert.Equal(t, []string{"Cats", "Foxes", "Dogs"}, got)
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		as[1].Body = "A lazy dog chases a fox"
+		assert.NoError(t, db.Save(as[1]))
+		assert.ElementsMatch(t, []string{"Foxes", "Dogs", "Cats"}, titles(where.Match("Body", "fox")))
+		assert.Empty(t, titles(where.Match("Body", "sleeps")))
+
+		n, err := db.UpdateMany(&Article{}, where.Equal("Title", "Cats"), map[string]interface{}{"Body": "Cats sleep"})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.ElementsMatch(t, []string{"Foxes", "Dogs"}, titles(where.Match("Body", "fox")))
+
+		assert.NoError(t, db.Remove(as[0]))
+		assert.ElementsMatch(t, []string{"Dogs"}, titles(where.Match("Body", "fox")))
+	})
+
+	t.Run("cursor", func(t *testing.T) {
+		_, err := mud.PaginateCursor[Article](db, "", 10, order.Relevance("Body", "fox"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/markoxley/mud"
+	"github.com/stretchr/testify/assert"
+)
+
+// traceRecorder is an instrumenter that records the operations it sees
+type traceRecorder struct {
+	started []mud.Operation
+	ended   []mud.OperationResult
+}
+
+type traceKey struct{}
+
+func (r *traceRecorder) Start(ctx context.Context, op mud.Operation) context.Context {
+	r.started = append(r.started, op)
+	return context.WithValue(ctx, traceKey{}, len(r.started))
+}
+
+func (r *traceRecorder) End(ctx context.Context, op mud.Operation, res mud.OperationResult) {
+	if ctx.Value(traceKey{}) == nil {
+		panic("context from Start not passed to End")
+	}
+	r.ended = append(r.ended, res)
+}
+
+func TestInstrumentation(t *testing.T) {
+	db, err := mud.New(&mud.Config{
+		Type:         "sqlite",
+		Database:     filepath.Join(t.TempDir(), "mud_test.db"),
+		MaxOpenConns: 4,
+	})
+	assert.NoError(t, err)
+	defer db.Close()
+
+	metrics := mud.NewMetrics()
+	trace := &traceRecorder{}
+	db.SetInstrumenters(trace, metrics)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, db.Save(&TestModel{Name: fmt.Sprintf("user %d", i), Age: i}))
+	}
+	_, err = mud.Fetch[TestModel](db)
+	assert.NoError(t, err)
+	assert.Error(t, db.RawExecute("UPDATE missing_table SET x = 1"))
+
+	assert.Equal(t, len(trace.started), len(trace.ended))
+
+	stats := db.Stats()
+	assert.Equal(t, 4, stats.Pool.MaxOpenConnections)
+
+	find := func(name, table string) *mud.OperationStats {
+		for i, s := range stats.Operations {
+			if s.Name == name && s.Table == table {
+				return &stats.Operations[i]
+			}
+		}
+		return nil
+	}
+	if insert := find(mud.OpInsert, "TestModel"); assert.NotNil(t, insert) {
+		assert.Equal(t, int64(3), insert.Count)
+		assert.Equal(t, int64(0), insert.ErrorCount())
+		last := insert.Buckets[len(insert.Buckets)-1]
+		assert.Equal(t, int64(3), last.Count)
+		assert.LessOrEqual(t, insert.Mean(), insert.Max)
+	}
+	if sel := find(mud.OpSelect, "TestModel"); assert.NotNil(t, sel) {
+		assert.Equal(t, int64(1), sel.Count)
+	}
+	if raw := find(mud.OpRaw, ""); assert.NotNil(t, raw) {
+		assert.Equal(t, int64(1), raw.Errors[mud.ClassSyntax])
+	}
+
+	metrics.Reset()
+	assert.Empty(t, metrics.Snapshot())
+}
+
+func TestWithContext(t *testing.T) {
+	db := newSQLiteDB(t)
+	assert.NoError(t, db.Save(&TestModel{Name: "ctx"}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := mud.Fetch[TestModel](db.WithContext(ctx))
+	assert.Error(t, err)
+
+	// The original handle is unaffected
+	res, err := mud.Fetch[TestModel](db)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+}
+
+func TestDerivedClose(t *testing.T) {
+	db := newSQLiteDB(t)
+	assert.NoError(t, db.Save(&TestModel{Name: "derived"}))
+
+	tdb, err := db.ForTenant("acme")
+	assert.NoError(t, err)
+	for name, d := range map[string]*mud.DB{
+		"WithContext": db.WithContext(context.Background()),
+		"UsePrimary":  db.UsePrimary(),
+		"ForTenant":   tdb,
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Closing a derived handle leaves the connections of the original open
+			d.Close()
+			assert.Equal(t, 1, db.Count(&TestModel{}))
+			assert.Equal(t, 1, d.Count(&TestModel{}))
+		})
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want mud.ErrorClass
+	}{
+		{nil, mud.ClassNone},
+		{mud.NoResults("none"), mud.ClassNoRows},
+		{context.Canceled, mud.ClassCanceled},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), mud.ClassTimeout},
+		{errors.New("UNIQUE constraint failed: test.ID"), mud.ClassConstraint},
+		{errors.New("Error 1062: Duplicate entry '1' for key 'PRIMARY'"), mud.ClassConstraint},
+		{errors.New("Invalid object name 'missing'."), mud.ClassSyntax},
+		{errors.New("disk full"), mud.ClassOther},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, mud.ClassifyError(tt.err), fmt.Sprint(tt.err))
+	}
+}
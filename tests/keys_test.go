@@ -0,0 +1,108 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+package tests
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/markoxley/mud"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyGenerators(t *testing.T) {
+	guid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	tests := []struct {
+		name    string
+		gen     mud.IDGenerator
+		pattern *regexp.Regexp
+		ordered bool
+	}{
+		{name: "UUIDv4", gen: mud.UUIDv4, pattern: guid},
+		{name: "UUIDv7", gen: mud.UUIDv7, pattern: guid, ordered: true},
+		{name: "SequentialGUID", gen: mud.SequentialGUID, pattern: guid, ordered: true},
+		{name: "ULID", gen: mud.ULID, pattern: regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := ""
+			for i := 0; i < 100; i++ {
+				id, err := tt.gen.NewID()
+				assert.NoError(t, err)
+				assert.Regexp(t, tt.pattern, id)
+				assert.NotEqual(t, prev, id)
+				if tt.ordered {
+					assert.Greater(t, id, prev)
+				}
+				prev = id
+			}
+		})
+	}
+}
+
+// AutoModel is a test model with an auto-incrementing key
+type AutoModel struct {
+	mud.Model
+	Name string `mud:"size:64"`
+}
+
+func (AutoModel) KeyGenerator() mud.IDGenerator { return mud.AutoIncrement }
+
+// fixedGenerator supplies keys from a counter
+type fixedGenerator struct {
+	next int
+}
+
+func (g *fixedGenerator) NewID() (string, error) {
+	g.next++
+	return "key-" + string(rune('0'+g.next)), nil
+}
+
+var customKeys = &fixedGenerator{}
+
+// CustomKeyModel is a test model with a user supplied key generator
+type CustomKeyModel struct {
+	mud.Model
+	Name string `mud:"size:64"`
+}
+
+func (*CustomKeyModel) KeyGenerator() mud.IDGenerator { return customKeys }
+
+func TestAutoIncrementKey(t *testing.T) {
+	db := newSQLiteDB(t)
+
+	first := &AutoModel{Name: "first"}
+	assert.NoError(t, db.Save(first))
+	assert.Equal(t, "1", *first.ID)
+	assert.False(t, first.CreateDate.IsZero())
+
+	second := &AutoModel{Name: "second"}
+	assert.NoError(t, db.Save(second))
+	assert.Equal(t, "2", *second.ID)
+
+	fetched, err := mud.FromID[AutoModel](db, *second.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", fetched.Name)
+
+	// Updates keep the generated key
+	second.Name = "updated"
+	assert.NoError(t, db.Save(second))
+	assert.Equal(t, "2", *second.ID)
+	fetched, err = mud.FromID[AutoModel](db, "2")
+	assert.NoError(t, err)
+	assert.Equal(t, "updated", fetched.Name)
+}
+
+func TestCustomKeyGenerator(t *testing.T) {
+	db := newSQLiteDB(t)
+
+	m := &CustomKeyModel{Name: "custom"}
+	assert.NoError(t, db.Save(m))
+	assert.Equal(t, "key-1", *m.ID)
+
+	fetched, err := mud.FromID[CustomKeyModel](db, "key-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "custom", fetched.Name)
+}
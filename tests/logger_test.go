@@ -0,0 +1,111 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markoxley/mud"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryLogger(t *testing.T) {
+	db := newSQLiteDB(t)
+	events := make([]mud.QueryEvent, 0)
+	db.SetLogger(mud.LoggerFunc(func(ctx context.Context, e mud.QueryEvent) {
+		events = append(events, e)
+	}))
+
+	m := &TestModel{Name: "logged", Age: 40}
+	assert.NoError(t, db.Save(m))
+
+	var insert *mud.QueryEvent
+	for i, e := range events {
+		if strings.HasPrefix(e.Statement, "INSERT INTO") {
+			insert = &events[i]
+		}
+	}
+	if assert.NotNil(t, insert, "insert should be logged") {
+		assert.Contains(t, insert.Statement, "'logged'")
+		assert.Equal(t, int64(1), insert.Rows)
+		assert.NoError(t, insert.Err)
+	}
+
+	events = events[:0]
+	assert.Error(t, db.RawExecute("UPDATE missing_table SET x = 1"))
+	if assert.Len(t, events, 1) {
+		assert.Error(t, events[0].Err)
+	}
+
+	db.SetLogger(nil)
+	_, err := mud.Fetch[TestModel](db)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func TestSlogLogger(t *testing.T) {
+	tests := []struct {
+		name      string
+		logger    func(l *slog.Logger) *mud.SlogLogger
+		event     mud.QueryEvent
+		wantLevel string
+		want      []string
+		notWant   []string
+	}{
+		{
+			name:      "debug",
+			logger:    func(l *slog.Logger) *mud.SlogLogger { return mud.NewSlogLogger(l, time.Second) },
+			event:     mud.QueryEvent{Statement: "SELECT 1", Duration: time.Millisecond, Rows: -1},
+			wantLevel: "level=DEBUG",
+			want:      []string{"msg=query", `statement="SELECT 1"`},
+			notWant:   []string{"rows="},
+		},
+		{
+			name:      "slow",
+			logger:    func(l *slog.Logger) *mud.SlogLogger { return mud.NewSlogLogger(l, time.Second) },
+			event:     mud.QueryEvent{Statement: "DELETE FROM t", Duration: 2 * time.Second, Rows: 12},
+			wantLevel: "level=WARN",
+			want:      []string{`msg="slow query"`, "rows=12", "duration=2s"},
+		},
+		{
+			name:      "failed",
+			logger:    func(l *slog.Logger) *mud.SlogLogger { return mud.NewSlogLogger(l, 0) },
+			event:     mud.QueryEvent{Statement: "SELECT x", Rows: -1, Err: errors.New("no such column")},
+			wantLevel: "level=ERROR",
+			want:      []string{`msg="query failed"`, `error="no such column"`},
+		},
+		{
+			name: "redacted",
+			logger: func(l *slog.Logger) *mud.SlogLogger {
+				return &mud.SlogLogger{Logger: l, Level: slog.LevelInfo, Redact: true}
+			},
+			event:     mud.QueryEvent{Statement: "UPDATE t1 SET p = N'it''s secret', pin = 4721 WHERE n = 'bob' AND s > 1.5e3", Args: []interface{}{"pw"}, Rows: 1},
+			wantLevel: "level=INFO",
+			want:      []string{`statement="UPDATE t1 SET p = '?', pin = ? WHERE n = '?' AND s > ?"`, "args=[?]"},
+			notWant:   []string{"secret", "bob", "pw", "4721", "1.5e3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			tt.logger(l).LogQuery(context.Background(), tt.event)
+			out := buf.String()
+			assert.Contains(t, out, tt.wantLevel)
+			for _, w := range tt.want {
+				assert.Contains(t, out, w)
+			}
+			for _, w := range tt.notWant {
+				assert.NotContains(t, out, w)
+			}
+		})
+	}
+}
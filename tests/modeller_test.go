@@ -0,0 +1,180 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+package tests
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/markoxley/mud"
+	"github.com/markoxley/mud/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// newSQLiteDB opens a database in a temporary file so that
+// each test starts with an empty schema
+func newSQLiteDB(t *testing.T) *mud.DB {
+	db, err := mud.New(&mud.Config{
+		Type:     "sqlite",
+		Database: filepath.Join(t.TempDir(), "mud_test.db"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	return db
+}
+
+// DefaultModel is a test model with default values
+type DefaultModel struct {
+	mud.Model
+	Name    string    `mud:"size:64"`
+	Status  string    `mud:"size:16,default:active"`
+	Retries int       `mud:"default:3"`
+	Stamp   time.Time `mud:"dbDefault:CURRENT_TIMESTAMP"`
+}
+
+func TestDefaultValues(t *testing.T) {
+	db := newSQLiteDB(t)
+
+	// Zero values are left to the database
+	m := &DefaultModel{Name: "defaults"}
+	err := db.Save(m)
+	assert.NoError(t, err)
+	assert.False(t, m.Stamp.IsZero(), "database default should be read back")
+
+	fetched, err := mud.FromID[DefaultModel](db, *m.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "active", fetched.Status)
+	assert.Equal(t, 3, fetched.Retries)
+	assert.Equal(t, m.Stamp, fetched.Stamp)
+
+	// Explicit values are written
+	stamp := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	m = &DefaultModel{Name: "explicit", Status: "closed", Retries: 1, Stamp: stamp}
+	err = db.Save(m)
+	assert.NoError(t, err)
+
+	fetched, err = mud.FromID[DefaultModel](db, *m.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "closed", fetched.Status)
+	assert.Equal(t, 1, fetched.Retries)
+	assert.True(t, stamp.Equal(fetched.Stamp))
+}
+
+// PointerDefaultModel is a test model with defaulted pointer fields
+type PointerDefaultModel struct {
+	mud.Model
+	Active  *bool `mud:"default:true"`
+	Retries *int  `mud:"default:3"`
+}
+
+func TestPointerDefaultValues(t *testing.T) {
+	db := newSQLiteDB(t)
+
+	// Nil pointers are left to the database
+	m := &PointerDefaultModel{}
+	assert.NoError(t, db.Save(m))
+	fetched, err := mud.FromID[PointerDefaultModel](db, *m.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, fetched.Active) && assert.NotNil(t, fetched.Retries) {
+		assert.True(t, *fetched.Active)
+		assert.Equal(t, 3, *fetched.Retries)
+	}
+
+	// Pointers to zero values are written
+	m = &PointerDefaultModel{Active: utils.Ptr(false), Retries: utils.Ptr(0)}
+	assert.NoError(t, db.Save(m))
+	fetched, err = mud.FromID[PointerDefaultModel](db, *m.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, fetched.Active) && assert.NotNil(t, fetched.Retries) {
+		assert.False(t, *fetched.Active)
+		assert.Equal(t, 0, *fetched.Retries)
+	}
+}
+
+// WriteModel is a test model with restricted write columns
+type WriteModel struct {
+	mud.Model
+	CreatedBy string  `mud:"size:32,insertOnly"`
+	Locked    string  `mud:"size:32,readonly,default:fixed"`
+	Price     float64 `mud:""`
+	Qty       int     `mud:""`
+	Total     float64 `mud:"computed:(Price * Qty)"`
+}
+
+func TestWriteRestrictions(t *testing.T) {
+	db := newSQLiteDB(t)
+
+	m := &WriteModel{CreatedBy: "alice", Locked: "ignored", Price: 2.5, Qty: 4}
+	err := db.Save(m)
+	assert.NoError(t, err)
+	assert.Equal(t, "fixed", m.Locked, "readonly column should be read back")
+	assert.Equal(t, 10.0, m.Total, "computed column should be read back")
+
+	m.CreatedBy = "mallory"
+	m.Locked = "changed"
+	m.Qty = 2
+	err = db.Save(m)
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, m.Total)
+
+	fetched, err := mud.FromID[WriteModel](db, *m.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", fetched.CreatedBy)
+	assert.Equal(t, "fixed", fetched.Locked)
+	assert.Equal(t, 2, fetched.Qty)
+	assert.Equal(t, 5.0, fetched.Total)
+}
+
+// Address is a value object embedded in other models
+type Address struct {
+	Street string `mud:"size:64"`
+	City   string `mud:"size:32"`
+}
+
+// EmbedModel is a test model with embedded value objects
+type EmbedModel struct {
+	mud.Model
+	Name     string   `mud:"size:32"`
+	Billing  Address  `mud:"embed"`
+	Shipping Address  `mud:"embed,prefix:Ship"`
+	Previous *Address `mud:"embed,prefix:Old"`
+}
+
+func TestEmbeddedStructs(t *testing.T) {
+	db := newSQLiteDB(t)
+
+	m := &EmbedModel{
+		Name:     "embedded",
+		Billing:  Address{Street: "1 High Street", City: "London"},
+		Shipping: Address{Street: "2 Low Road", City: "Leeds"},
+	}
+	err := db.Save(m)
+	assert.NoError(t, err)
+
+	rows, err := db.RawSelect(`SELECT "BillingStreet", "ShipCity", "OldStreet" FROM "EmbedModel"`)
+	assert.NoError(t, err)
+	assert.Len(t, rows, 1)
+	assert.Equal(t, "1 High Street", *rows[0]["BillingStreet"].(*string))
+	assert.Equal(t, "Leeds", *rows[0]["ShipCity"].(*string))
+	assert.Nil(t, rows[0]["OldStreet"].(*string))
+
+	fetched, err := mud.FromID[EmbedModel](db, *m.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, m.Billing, fetched.Billing)
+	assert.Equal(t, m.Shipping, fetched.Shipping)
+	assert.Nil(t, fetched.Previous)
+
+	fetched.Previous = &Address{Street: "3 Old Lane", City: "York"}
+	err = db.Save(fetched)
+	assert.NoError(t, err)
+
+	fetched, err = mud.FromID[EmbedModel](db, *m.ID)
+	assert.NoError(t, err)
+	if assert.NotNil(t, fetched.Previous) {
+		assert.Equal(t, "3 Old Lane", fetched.Previous.Street)
+	}
+}
@@ -111,3 +111,22 @@ func TestBuilder(t *testing.T) {
 		})
 	}
 }
+
+func TestBuilderRender(t *testing.T) {
+	b := order.Asc("name").Desc("age")
+	brackets := func(f string) string { return "[" + f + "]" }
+	if got, want := b.Render(brackets), "[name] asc, [age] desc"; got != want {
+		t.Errorf("Builder.Render() = %v, want %v", got, want)
+	}
+
+	want := []order.Field{{Name: "name", Ascending: true}, {Name: "age", Ascending: false}}
+	got := b.Fields()
+	if len(got) != len(want) {
+		t.Fatalf("Builder.Fields() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Builder.Fields()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+package tests
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/markoxley/mud"
+	"github.com/markoxley/mud/order"
+	"github.com/markoxley/mud/where"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginate(t *testing.T) {
+	db := newSQLiteDB(t)
+	for i := 1; i <= 7; i++ {
+		assert.NoError(t, db.Save(&TestModel{Name: fmt.Sprintf("user %d", i), Age: i}))
+	}
+	assert.NoError(t, db.Save(&TestModel{Name: "other", Age: 100}))
+	c := mud.Criteria{Where: where.Less("Age", 100), Order: `"Age" DESC`}
+
+	tests := []struct {
+		page     int
+		wantAges []int
+		hasPrev  bool
+		hasNext  bool
+	}{
+		{page: 1, wantAges: []int{7, 6, 5}, hasNext: true},
+		{page: 2, wantAges: []int{4, 3, 2}, hasPrev: true, hasNext: true},
+		{page: 3, wantAges: []int{1}, hasPrev: true},
+		{page: 4, wantAges: []int{}, hasPrev: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("page %d", tt.page), func(t *testing.T) {
+			p, err := mud.Paginate[TestModel](db, tt.page, 3, c)
+			assert.NoError(t, err)
+			assert.Equal(t, 7, p.Total)
+			assert.Equal(t, 3, p.Pages)
+			assert.Equal(t, tt.page, p.Page)
+			assert.Equal(t, tt.hasPrev, p.HasPrev)
+			assert.Equal(t, tt.hasNext, p.HasNext)
+			ages := make([]int, 0, len(p.Items))
+			for _, m := range p.Items {
+				ages = append(ages, m.Age)
+			}
+			assert.Equal(t, tt.wantAges, ages)
+		})
+	}
+
+	// Pages without an order are ordered by ID
+	seen := map[string]bool{}
+	for page := 1; page <= 3; page++ {
+		p, err := mud.Paginate[TestModel](db, page, 3)
+		assert.NoError(t, err)
+		for _, m := range p.Items {
+			assert.False(t, seen[*m.ID], "model returned on more than one page")
+			seen[*m.ID] = true
+		}
+	}
+	assert.Len(t, seen, 8)
+
+	_, err := mud.Paginate[TestModel](db, 1, 0)
+	assert.Error(t, err)
+}
+
+func TestPaginateTiebreak(t *testing.T) {
+	db := newSQLiteDB(t)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, db.Save(&TestModel{Name: fmt.Sprintf("tie %d", i), Age: 50}))
+	}
+	var log statements
+	db.SetLogger(log.logger())
+
+	// Models with the same age are ordered by ID, so none repeat or go missing
+	ids := []string{}
+	for page := 1; page <= 3; page++ {
+		p, err := mud.Paginate[TestModel](db, page, 2, order.Asc("Age"))
+		assert.NoError(t, err)
+		for _, m := range p.Items {
+			ids = append(ids, *m.ID)
+		}
+	}
+	assert.Len(t, ids, 5)
+	assert.True(t, slices.IsSorted(ids))
+	for _, st := range log.list {
+		if strings.HasPrefix(st, "SELECT * FROM") {
+			assert.Contains(t, st, `ORDER BY "Age" asc, "ID" asc`)
+		}
+	}
+
+	// An order that already includes ID is left alone
+	log.list = nil
+	_, err := mud.Paginate[TestModel](db, 1, 2, order.Desc("ID"))
+	assert.NoError(t, err)
+	for _, st := range log.list {
+		if strings.HasPrefix(st, "SELECT * FROM") {
+			assert.Contains(t, st, `ORDER BY "ID" desc LIMIT`)
+		}
+	}
+}
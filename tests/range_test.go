@@ -0,0 +1,93 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/markoxley/mud"
+	"github.com/markoxley/mud/where"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRange(t *testing.T) {
+	db := newSQLiteDB(t)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, db.Save(&TestModel{Name: fmt.Sprintf("user %d", i), Age: i}))
+	}
+
+	n := 0
+	for m := range mud.Range[TestModel](db, where.Greater("Age", 1)) {
+		assert.Greater(t, m.Age, 1)
+		n++
+	}
+	assert.Equal(t, 3, n)
+
+	// Pointer and value models are both supported
+	n = 0
+	for m := range db.Range(&TestModel{}) {
+		_, ok := m.(*TestModel)
+		assert.True(t, ok)
+		n++
+	}
+	assert.Equal(t, 5, n)
+	for m := range db.Range(TestModel{}) {
+		_, ok := m.(TestModel)
+		assert.True(t, ok)
+		break
+	}
+}
+
+func TestRangeErr(t *testing.T) {
+	db := newSQLiteDB(t)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, db.Save(&TestModel{Name: fmt.Sprintf("user %d", i), Age: i}))
+	}
+
+	n := 0
+	for m, err := range mud.RangeErr[TestModel](db) {
+		assert.NoError(t, err)
+		assert.NotNil(t, m)
+		n++
+	}
+	assert.Equal(t, 5, n)
+
+	// Invalid criteria are reported
+	var errs []error
+	for m, err := range mud.RangeErr[TestModel](db, 42) {
+		assert.Nil(t, m)
+		errs = append(errs, err)
+	}
+	if assert.Len(t, errs, 1) {
+		assert.Error(t, errs[0])
+	}
+
+	// Query errors are reported
+	errs = nil
+	for _, err := range mud.RangeErr[TestModel](db, "Missing = 1") {
+		errs = append(errs, err)
+	}
+	if assert.Len(t, errs, 1) {
+		assert.Error(t, errs[0])
+	}
+
+	// Cancellation between rows is reported
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n = 0
+	var last error
+	for m, err := range mud.RangeErr[TestModel](db.WithContext(ctx)) {
+		if err != nil {
+			last = err
+			break
+		}
+		assert.NotNil(t, m)
+		n++
+		cancel()
+	}
+	assert.Equal(t, 1, n)
+	assert.ErrorIs(t, last, context.Canceled)
+}
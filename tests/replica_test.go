@@ -0,0 +1,221 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+package tests
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/markoxley/mud"
+	"github.com/markoxley/mud/where"
+	"github.com/stretchr/testify/assert"
+)
+
+// newReplicaDB opens the database at path and stores a model named name
+func newReplicaDB(t *testing.T, path, name string) {
+	db, err := mud.New(&mud.Config{Type: "sqlite", Database: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	assert.NoError(t, db.Save(&TestModel{Name: name}))
+}
+
+func TestReplicaRouting(t *testing.T) {
+	dir := t.TempDir()
+	primary := filepath.Join(dir, "primary.db")
+	replicas := []string{filepath.Join(dir, "replica1.db"), filepath.Join(dir, "replica2.db")}
+	newReplicaDB(t, primary, "primary")
+	newReplicaDB(t, replicas[0], "replica1")
+	newReplicaDB(t, replicas[1], "replica2")
+
+	db, err := mud.New(&mud.Config{
+		Type:     "sqlite",
+		Database: primary,
+		Replicas: []mud.Config{{Database: replicas[0]}, {Database: replicas[1]}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	names := func(d *mud.DB) []string {
+		res := []string{}
+		for i := 0; i < 4; i++ {
+			m, err := mud.First[TestModel](d)
+			assert.NoError(t, err)
+			res = append(res, m.Name)
+		}
+		return res
+	}
+
+	t.Run("reads round robin", func(t *testing.T) {
+		assert.Equal(t, []string{"replica1", "replica2", "replica1", "replica2"}, names(db))
+	})
+
+	t.Run("use primary", func(t *testing.T) {
+		assert.Equal(t, []string{"primary", "primary", "primary", "primary"}, names(db.UsePrimary()))
+	})
+
+	t.Run("writes go to primary", func(t *testing.T) {
+		m := &TestModel{Name: "written", Age: 99}
+		assert.NoError(t, db.Save(m))
+		assert.Equal(t, 0, db.Count(&TestModel{}, where.Equal("Age", 99)))
+		assert.Equal(t, 1, db.UsePrimary().Count(&TestModel{}, where.Equal("Age", 99)))
+		assert.NoError(t, db.Refresh(m))
+		assert.Equal(t, "written", m.Name)
+	})
+
+	t.Run("transactions use primary", func(t *testing.T) {
+		tx, err := db.BeginTransaction()
+		assert.NoError(t, err)
+		defer db.RollbackTransaction(tx)
+		rows, err := db.RawSelect("SELECT Name FROM TestModel WHERE Age = 99", tx)
+		assert.NoError(t, err)
+		assert.Len(t, rows, 1)
+	})
+
+	t.Run("balancer", func(t *testing.T) {
+		db.SetBalancer(mud.BalancerFunc(func(r []*mud.Replica) *mud.Replica { return r[len(r)-1] }))
+		defer db.SetBalancer(nil)
+		assert.Equal(t, []string{"replica2", "replica2", "replica2", "replica2"}, names(db))
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		stats := db.Stats()
+		assert.Len(t, stats.Replicas, 2)
+		assert.Equal(t, replicas[0], stats.Replicas[0].Name)
+		assert.True(t, stats.Replicas[0].Healthy)
+	})
+}
+
+func TestReplicaEjection(t *testing.T) {
+	dir := t.TempDir()
+	primary := filepath.Join(dir, "primary.db")
+	replica := filepath.Join(dir, "replica.db")
+	newReplicaDB(t, primary, "primary")
+	newReplicaDB(t, replica, "replica")
+
+	db, err := mud.New(&mud.Config{
+		Type:                "sqlite",
+		Database:            primary,
+		HealthCheckInterval: 10 * time.Millisecond,
+		Replicas: []mud.Config{
+			{Database: filepath.Join(dir, "missing", "replica.db")},
+			{Database: replica},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rs := db.Replicas()
+	assert.Len(t, rs, 2)
+	assert.False(t, rs[0].Healthy())
+	assert.True(t, rs[1].Healthy())
+
+	// The balancer is only offered the healthy replica
+	db.SetBalancer(mud.LeastConnections())
+	for i := 0; i < 3; i++ {
+		m, err := mud.First[TestModel](db)
+		assert.NoError(t, err)
+		assert.Equal(t, "replica", m.Name)
+	}
+
+	// With no healthy replica, reads fall back to the primary
+	only, err := mud.New(&mud.Config{
+		Type:                "sqlite",
+		Database:            primary,
+		HealthCheckInterval: 10 * time.Millisecond,
+		Replicas:            []mud.Config{{Database: filepath.Join(dir, "missing", "replica.db")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer only.Close()
+	m, err := mud.First[TestModel](only)
+	assert.NoError(t, err)
+	assert.Equal(t, "primary", m.Name)
+}
+
+func TestReplicaHealthCheckTimeout(t *testing.T) {
+	// The replica accepts connections but never answers
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer c.Close()
+		}
+	}()
+
+	start := time.Now()
+	db, err := mud.New(&mud.Config{
+		Type: "mysql", Host: "127.0.0.1", Database: "db", User: "u", Password: "p",
+		Replicas: []mud.Config{{Host: l.Addr().String()}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// The health check gives up well before the default interval
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.False(t, db.Replicas()[0].Healthy())
+}
+
+func TestReplicaConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *mud.Config
+		wantErr bool
+	}{
+		{
+			name: "inherits settings",
+			config: &mud.Config{Type: "mysql", Host: "primary", Database: "db", User: "u", Password: "p",
+				Replicas: []mud.Config{{Host: "replica1"}, {Host: "replica2", Port: 3307}}},
+		},
+		{
+			name: "type mismatch",
+			config: &mud.Config{Type: "mysql", Host: "primary", Database: "db", User: "u", Password: "p",
+				Replicas: []mud.Config{{Type: "mssql", Host: "replica1"}}},
+			wantErr: true,
+		},
+		{
+			name: "negative interval",
+			config: &mud.Config{Type: "sqlite", Database: "db.sqlite", HealthCheckInterval: -time.Second,
+				Replicas: []mud.Config{{Database: "replica.sqlite"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Setenv("MUD_REPLICA_DSN", "mysql://u:p@primary:3306/db")
+	t.Setenv("MUD_REPLICA_REPLICAS", "mysql://replica1, mysql://replica2:3307")
+	t.Setenv("MUD_REPLICA_HEALTH_CHECK_INTERVAL", "5s")
+	cfg, err := mud.ConfigFromEnv("MUD_REPLICA")
+	assert.NoError(t, err)
+	if assert.Len(t, cfg.Replicas, 2) {
+		assert.Equal(t, "replica1", cfg.Replicas[0].Host)
+		assert.Equal(t, 3307, cfg.Replicas[1].Port)
+	}
+	assert.Equal(t, 5*time.Second, cfg.HealthCheckInterval)
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+package tests
+
+import (
+	"testing"
+
+	"github.com/markoxley/mud"
+	"github.com/markoxley/mud/order"
+	"github.com/markoxley/mud/where"
+	"github.com/stretchr/testify/assert"
+)
+
+// ScopedModel is a test model that only returns active models by default
+type ScopedModel struct {
+	mud.Model
+	Name   string `mud:"size:64"`
+	Active bool   `mud:""`
+	Rank   int    `mud:""`
+}
+
+func (m *ScopedModel) DefaultScope() mud.Scope {
+	return mud.Scope{Where: where.Equal("Active", true), Order: order.Desc("Rank")}
+}
+
+func TestDefaultScope(t *testing.T) {
+	db := newSQLiteDB(t)
+	models := []*ScopedModel{
+		{Name: "a", Active: true, Rank: 1},
+		{Name: "b", Active: false, Rank: 2},
+		{Name: "c", Active: true, Rank: 3},
+		{Name: "d", Active: true, Rank: 2},
+	}
+	for _, m := range models {
+		assert.NoError(t, db.Save(m))
+	}
+	names := func(ms []*ScopedModel) []string {
+		res := make([]string, 0, len(ms))
+		for _, m := range ms {
+			res = append(res, m.Name)
+		}
+		return res
+	}
+
+	tests := []struct {
+		name     string
+		criteria interface{}
+		want     []string
+	}{
+		{name: "scope", want: []string{"c", "d", "a"}},
+		{name: "combined with where", criteria: where.Less("Rank", 3), want: []string{"d", "a"}},
+		{name: "own order", criteria: order.Asc("Name"), want: []string{"a", "c", "d"}},
+		{name: "unscoped", criteria: mud.Criteria{Order: "Name"}.Unscoped(), want: []string{"a", "b", "c", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms, err := mud.Fetch[ScopedModel](db, tt.criteria)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, names(ms))
+		})
+	}
+
+	t.Run("first and count", func(t *testing.T) {
+		m, err := mud.First[ScopedModel](db)
+		assert.NoError(t, err)
+		assert.Equal(t, "c", m.Name)
+		_, err = mud.First[ScopedModel](db, where.Equal("Name", "b"))
+		assert.Error(t, err)
+		assert.Equal(t, 3, db.Count(&ScopedModel{}))
+		assert.Equal(t, 4, db.Count(&ScopedModel{}, mud.Criteria{}.Unscoped()))
+	})
+
+	t.Run("range and paginate", func(t *testing.T) {
+		got := []string{}
+		for m := range mud.Range[ScopedModel](db) {
+			got = append(got, m.Name)
+		}
+		assert.Equal(t, []string{"c", "d", "a"}, got)
+
+		page, err := mud.Paginate[ScopedModel](db, 1, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, page.Total)
+		assert.Equal(t, []string{"c", "d"}, names(page.Items))
+	})
+
+	t.Run("remove many", func(t *testing.T) {
+		n, err := db.RemoveMany(&ScopedModel{}, &mud.Criteria{Where: where.Less("Rank", 3)})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+		ms, err := mud.Fetch[ScopedModel](db, mud.Criteria{Order: "Name"}.Unscoped())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"b", "c"}, names(ms))
+	})
+
+	t.Run("remove ignores scope", func(t *testing.T) {
+		assert.NoError(t, db.Remove(models[1]))
+		assert.Equal(t, 1, db.Count(&ScopedModel{}, mud.Criteria{}.Unscoped()))
+	})
+}
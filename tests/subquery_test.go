@@ -0,0 +1,146 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+package tests
+
+import (
+	"testing"
+
+	"github.com/markoxley/mud"
+	"github.com/markoxley/mud/where"
+	"github.com/stretchr/testify/assert"
+)
+
+// Customer is a test model referred to by Purchase
+type Customer struct {
+	mud.Model
+	Name    string `mud:"size:64"`
+	Country string `mud:"size:2"`
+}
+
+// Purchase is a test model that belongs to a Customer
+type Purchase struct {
+	mud.Model
+	CustomerID string `mud:"size:36"`
+	Amount     int    `mud:""`
+}
+
+// Review is a test model with a full-text searched field that belongs to a Customer
+type Review struct {
+	mud.Model
+	CustomerID string `mud:"size:36"`
+	Body       string `mud:"fulltext,size:1000"`
+}
+
+func TestSubqueryString(t *testing.T) {
+	uk := mud.Subquery[Customer]("ID", where.Equal("Country", "UK"))
+	tests := []struct {
+		mgr  mud.Manager
+		name string
+		in   *where.Builder
+		out  string
+	}{
+		{mgr: &mud.SqliteManager{}, name: "SQLite In", in: where.InQuery("CustomerID", uk), out: "\"CustomerID\" IN (SELECT \"ID\" FROM \"Customer\" WHERE \"Country\" = 'UK' AND \"DeleteDate\" IS NULL)"},
+		{mgr: &mud.MySQLManager{}, name: "MySQL Not In", in: where.NotInQuery("CustomerID", uk), out: "`CustomerID` NOT IN (SELECT `ID` FROM `Customer` WHERE `Country` = 'UK' AND `DeleteDate` IS NULL)"},
+		{mgr: &mud.MSSQLManager{}, name: "MSSQL Exists", in: where.Exists(mud.Subquery[Purchase]("", where.Equal("CustomerID", where.Col("Customer.ID")))), out: "EXISTS (SELECT 1 FROM [Purchase] WHERE [CustomerID] = [Customer].[ID] AND [DeleteDate] IS NULL)"},
+		{mgr: &mud.SqliteManager{}, name: "SQLite Deleted", in: where.Equal("Amount", 1).OrInQuery("CustomerID", mud.Subquery[Customer]("ID", mud.Criteria{IncDeleted: true})), out: "\"Amount\" = 1 OR \"CustomerID\" IN (SELECT \"ID\" FROM \"Customer\")"},
+		{mgr: &mud.SqliteManager{}, name: "SQLite Invalid", in: where.Exists(mud.Subquery[Customer]("ID", where.In("Country", []string{}))), out: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.out, tt.in.String(tt.mgr.Operators()))
+		})
+	}
+}
+
+func TestSubquery(t *testing.T) {
+	db := newSQLiteDB(t)
+	cs := []*Customer{{Name: "Ann", Country: "UK"}, {Name: "Bob", Country: "UK"}, {Name: "Cat", Country: "US"}, {Name: "Dan", Country: "US"}}
+	for _, c := range cs {
+		assert.NoError(t, db.Save(c))
+	}
+	for i, c := range cs[:3] {
+		assert.NoError(t, db.Save(&Purchase{CustomerID: *c.ID, Amount: i + 1}))
+	}
+	// Purchases of removed customers are not matched
+	assert.NoError(t, db.Remove(cs[1]))
+
+	ps, err := mud.Fetch[Purchase](db, where.InQuery("CustomerID", mud.Subquery[Customer]("ID", where.Equal("Country", "UK"))))
+	assert.NoError(t, err)
+	if assert.Len(t, ps, 1) {
+		assert.Equal(t, *cs[0].ID, ps[0].CustomerID)
+	}
+	assert.Equal(t, 2, db.Count(&Purchase{}, where.NotInQuery("CustomerID", mud.Subquery[Customer]("ID", where.Equal("Country", "US")))))
+
+	buyers, err := mud.Fetch[Customer](db, where.Exists(mud.Subquery[Purchase]("", where.Equal("CustomerID", where.Col("Customer.ID")))))
+	assert.NoError(t, err)
+	assert.Len(t, buyers, 2)
+	others, err := mud.Fetch[Customer](db, where.NotExists(mud.Subquery[Purchase]("", where.Equal("CustomerID", where.Col("Customer.ID")))))
+	assert.NoError(t, err)
+	if assert.Len(t, others, 1) {
+		assert.Equal(t, "Dan", others[0].Name)
+	}
+}
+
+func TestSubqueryTenant(t *testing.T) {
+	db := newSQLiteDB(t)
+	acme, err := db.ForTenant("acme")
+	assert.NoError(t, err)
+	globex, err := db.ForTenant("globex")
+	assert.NoError(t, err)
+	assert.NoError(t, db.Save(&Customer{Name: "g1", Country: "UK"}))
+	assert.NoError(t, globex.Save(&TenantModel{Name: "g1"}))
+
+	// The rows of other tenants cannot be probed from within a subquery
+	probe := where.Exists(mud.Subquery[TenantModel]("", where.Equal("Name", "g1")))
+	cs, err := mud.Fetch[Customer](acme, probe)
+	assert.NoError(t, err)
+	assert.Empty(t, cs)
+	assert.Equal(t, 0, acme.Count(&Customer{}, where.InQuery("Name", mud.Subquery[TenantModel]("Name"))))
+
+	cs, err = mud.Fetch[Customer](globex, probe)
+	assert.NoError(t, err)
+	assert.Len(t, cs, 1)
+
+	_, err = mud.Fetch[Customer](db, probe)
+	assert.ErrorIs(t, err, mud.ErrNoTenant)
+	// Outside of a query the tenant is unknown, so the subquery is invalid
+	assert.Equal(t, "", probe.String((&mud.SqliteManager{}).Operators()))
+}
+
+func TestSubqueryMatch(t *testing.T) {
+	db := newSQLiteDB(t)
+	cs := []*Customer{{Name: "Ann", Country: "UK"}, {Name: "Bob", Country: "US"}}
+	for _, c := range cs {
+		assert.NoError(t, db.Save(c))
+	}
+	assert.NoError(t, db.Save(&Review{CustomerID: *cs[0].ID, Body: "The quick brown fox"}))
+	assert.NoError(t, db.Save(&Review{CustomerID: *cs[1].ID, Body: "A lazy dog"}))
+
+	// The full-text search of the subquery refers to the table of its own model
+	got, err := mud.Fetch[Customer](db, where.InQuery("ID", mud.Subquery[Review]("CustomerID", where.Match("Body", "fox"))))
+	assert.NoError(t, err)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, "Ann", got[0].Name)
+	}
+
+	rs, err := mud.Fetch[Review](db, where.NotMatch("Body", "fox").AndInQuery("CustomerID", mud.Subquery[Customer]("ID", where.Equal("Country", "US"))))
+	assert.NoError(t, err)
+	if assert.Len(t, rs, 1) {
+		assert.Equal(t, "A lazy dog", rs[0].Body)
+	}
+}
+
+func TestSubqueryInvalid(t *testing.T) {
+	db := newSQLiteDB(t)
+	c := &Customer{Name: "Ann", Country: "UK"}
+	assert.NoError(t, db.Save(c))
+	assert.NoError(t, db.Save(&Purchase{CustomerID: *c.ID, Amount: 1}))
+
+	// An invalid subquery fails the query rather than matching every row
+	_, err := mud.Fetch[Purchase](db, where.InQuery("CustomerID", mud.Subquery[Customer]("ID", 42)))
+	assert.Error(t, err)
+	_, err = mud.Fetch[Purchase](db, where.InQuery("CustomerID", mud.Subquery[Customer]("ID", where.In("Country", []string{}))))
+	assert.ErrorIs(t, err, mud.ErrInvalidCondition)
+	assert.Equal(t, 0, db.Count(&Purchase{}, where.Exists(mud.Subquery[Customer]("", 42))))
+}
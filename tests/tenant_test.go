@@ -0,0 +1,172 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/markoxley/mud"
+	"github.com/markoxley/mud/where"
+	"github.com/stretchr/testify/assert"
+)
+
+// TenantModel is a test model owned by a tenant
+type TenantModel struct {
+	mud.Model
+	TenantID string `mud:"tenant,size:64"`
+	Name     string `mud:"size:64"`
+}
+
+func TestTenantColumn(t *testing.T) {
+	db := newSQLiteDB(t)
+	acme, err := db.ForTenant("acme")
+	assert.NoError(t, err)
+	globex, err := db.ForTenant("globex")
+	assert.NoError(t, err)
+
+	for _, n := range []string{"a1", "a2", "a3"} {
+		assert.NoError(t, acme.Save(&TenantModel{Name: n}))
+	}
+	g := &TenantModel{Name: "g1"}
+	assert.NoError(t, globex.Save(g))
+	assert.Equal(t, "globex", g.TenantID)
+
+	t.Run("reads are scoped", func(t *testing.T) {
+		ms, err := mud.Fetch[TenantModel](acme)
+		assert.NoError(t, err)
+		assert.Len(t, ms, 3)
+		for _, m := range ms {
+			assert.Equal(t, "acme", m.TenantID)
+		}
+		assert.Equal(t, 1, globex.Count(&TenantModel{}))
+		_, err = mud.First[TenantModel](acme, where.Equal("Name", "g1"))
+		assert.Error(t, err)
+		_, err = mud.FromID[TenantModel](acme, *g.ID)
+		assert.Error(t, err)
+
+		n := 0
+		for m, err := range mud.RangeErr[TenantModel](globex) {
+			assert.NoError(t, err)
+			assert.Equal(t, "g1", m.Name)
+			n++
+		}
+		assert.Equal(t, 1, n)
+
+		page, err := mud.Paginate[TenantModel](acme, 1, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, page.Total)
+	})
+
+	t.Run("no tenant", func(t *testing.T) {
+		_, err := mud.Fetch[TenantModel](db)
+		assert.ErrorIs(t, err, mud.ErrNoTenant)
+		assert.ErrorIs(t, db.Save(&TenantModel{Name: "orphan"}), mud.ErrNoTenant)
+		_, err = db.RemoveMany(&TenantModel{}, &mud.Criteria{})
+		assert.ErrorIs(t, err, mud.ErrNoTenant)
+		_, err = db.ForTenant("")
+		assert.ErrorIs(t, err, mud.ErrInvalidTenant)
+	})
+
+	t.Run("context tenant", func(t *testing.T) {
+		ctxDB := db.WithContext(mud.WithTenant(context.Background(), "globex"))
+		ms, err := mud.Fetch[TenantModel](ctxDB)
+		assert.NoError(t, err)
+		assert.Len(t, ms, 1)
+		m := &TenantModel{Name: "g2"}
+		assert.NoError(t, ctxDB.Save(m))
+		assert.Equal(t, "globex", m.TenantID)
+	})
+
+	t.Run("cross tenant writes", func(t *testing.T) {
+		g.Name = "stolen"
+		assert.ErrorIs(t, acme.Save(g), mud.ErrCrossTenant)
+		assert.ErrorIs(t, acme.Remove(g), mud.ErrCrossTenant)
+		assert.ErrorIs(t, acme.Save(&TenantModel{TenantID: "globex", Name: "planted"}), mud.ErrCrossTenant)
+
+		// Changing the tenant of a model does not move the row
+		g.TenantID = "acme"
+		assert.ErrorIs(t, acme.Save(g), mud.ErrCrossTenant)
+		assert.ErrorIs(t, acme.Remove(g), mud.ErrCrossTenant)
+		_, err := acme.Update(g, mud.Set("Name", "stolen"))
+		assert.ErrorIs(t, err, mud.ErrCrossTenant)
+		stored, err := mud.FromID[TenantModel](globex, *g.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "g1", stored.Name)
+		assert.Equal(t, "globex", stored.TenantID)
+
+		// Writes that change nothing in the tenant's own rows are not errors
+		own := &TenantModel{Name: "a4"}
+		assert.NoError(t, acme.Save(own))
+		assert.NoError(t, acme.Remove(own))
+		assert.NoError(t, acme.Remove(own))
+	})
+
+	t.Run("remove many is scoped", func(t *testing.T) {
+		n, err := acme.RemoveMany(&TenantModel{}, &mud.Criteria{})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, n)
+		assert.Equal(t, 0, acme.Count(&TenantModel{}))
+		assert.Equal(t, 2, globex.Count(&TenantModel{}))
+	})
+}
+
+func TestTenantDatabase(t *testing.T) {
+	dir := t.TempDir()
+	db, err := mud.New(&mud.Config{
+		Type:           "sqlite",
+		Database:       filepath.Join(dir, "shared.db"),
+		Tenancy:        mud.TenancyDatabase,
+		TenantDatabase: filepath.Join(dir, "tenant_{tenant}.db"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	acme, err := db.ForTenant("acme")
+	assert.NoError(t, err)
+	globex, err := db.ForTenant("globex")
+	assert.NoError(t, err)
+	assert.NoError(t, acme.Save(&TestModel{Name: "a1"}))
+	assert.NoError(t, acme.Save(&TestModel{Name: "a2"}))
+	assert.NoError(t, globex.Save(&TestModel{Name: "g1"}))
+
+	assert.Equal(t, 2, acme.Count(&TestModel{}))
+	assert.Equal(t, 1, globex.Count(&TestModel{}))
+	_, err = os.Stat(filepath.Join(dir, "tenant_acme.db"))
+	assert.NoError(t, err)
+
+	again, err := db.ForTenant("acme")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, again.Count(&TestModel{}))
+
+	_, err = db.ForTenant("../escape")
+	assert.ErrorIs(t, err, mud.ErrInvalidTenant)
+}
+
+func TestTenantConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *mud.Config
+		wantErr bool
+	}{
+		{name: "column", config: &mud.Config{Type: "sqlite", Database: "a.db"}},
+		{name: "database", config: &mud.Config{Type: "sqlite", Database: "a.db", Tenancy: mud.TenancyDatabase, TenantDatabase: "t_{tenant}.db"}},
+		{name: "no placeholder", config: &mud.Config{Type: "sqlite", Database: "a.db", Tenancy: mud.TenancyDatabase, TenantDatabase: "t.db"}, wantErr: true},
+		{name: "invalid mode", config: &mud.Config{Type: "sqlite", Database: "a.db", Tenancy: "schema"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markoxley/mud"
+	"github.com/markoxley/mud/where"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateMany(t *testing.T) {
+	db := newSQLiteDB(t)
+	var log statements
+	db.SetLogger(log.logger())
+
+	ms := []*TestModel{{Name: "Ann", Age: 30}, {Name: "Bob", Age: 40}, {Name: "Cat", Age: 50}, {Name: "Dan", Age: 60}}
+	for _, m := range ms {
+		assert.NoError(t, db.Save(m))
+	}
+	assert.NoError(t, db.Remove(ms[3]))
+	before := ms[1].LastUpdate
+
+	log.list = nil
+	time.Sleep(10 * time.Millisecond)
+	n, err := db.UpdateMany(&TestModel{}, where.Greater("Age", 35), map[string]interface{}{"Name": "Old"})
+	assert.NoError(t, err)
+	// The removed model is not updated
+	assert.Equal(t, 2, n)
+	if assert.Len(t, log.updates(), 1) {
+		assert.Contains(t, log.updates()[0], "LastUpdate")
+	}
+
+	got, err := mud.Fetch[TestModel](db, where.Equal("Name", "Old"))
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+	for _, m := range got {
+		assert.True(t, m.LastUpdate.After(before))
+	}
+	m, err := mud.FromID[TestModel](db, *ms[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Ann", m.Name)
+
+	tests := []struct {
+		name   string
+		values map[string]interface{}
+	}{
+		{name: "no values", values: map[string]interface{}{}},
+		{name: "unknown field", values: map[string]interface{}{"Missing": 1}},
+		{name: "ID", values: map[string]interface{}{"ID": "x"}},
+		{name: "CreateDate", values: map[string]interface{}{"CreateDate": time.Now()}},
+		{name: "null", values: map[string]interface{}{"Age": nil}},
+		{name: "invalid value", values: map[string]interface{}{"Age": []int{1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := db.UpdateMany(&TestModel{}, nil, tt.values)
+			assert.Error(t, err)
+		})
+	}
+	t.Run("empty in", func(t *testing.T) {
+		// A condition that cannot be rendered must not update every row
+		n, err := db.UpdateMany(&TestModel{}, where.In("ID", []string{}), map[string]interface{}{"Name": "All"})
+		assert.ErrorIs(t, err, mud.ErrInvalidCondition)
+		assert.Equal(t, 0, n)
+		assert.Equal(t, 0, db.Count(&TestModel{}, where.Equal("Name", "All")))
+	})
+}
+
+func TestUpdateManyTenant(t *testing.T) {
+	db := newSQLiteDB(t)
+	acme, err := db.ForTenant("acme")
+	assert.NoError(t, err)
+	globex, err := db.ForTenant("globex")
+	assert.NoError(t, err)
+	assert.NoError(t, acme.Save(&TenantModel{Name: "a"}))
+	assert.NoError(t, globex.Save(&TenantModel{Name: "g"}))
+
+	n, err := acme.UpdateMany(&TenantModel{}, nil, map[string]interface{}{"Name": "x"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	g, err := mud.First[TenantModel](globex)
+	assert.NoError(t, err)
+	assert.Equal(t, "g", g.Name)
+
+	_, err = acme.UpdateMany(&TenantModel{}, nil, map[string]interface{}{"TenantID": "globex"})
+	assert.ErrorIs(t, err, mud.ErrCrossTenant)
+	_, err = db.UpdateMany(&TenantModel{}, nil, map[string]interface{}{"Name": "x"})
+	assert.ErrorIs(t, err, mud.ErrNoTenant)
+}
+
+func TestUpdateManyAudit(t *testing.T) {
+	db := newAuditDB(t, false)
+	m := &TestModel{Name: "Ann", Age: 30}
+	assert.NoError(t, db.Save(m))
+
+	n, err := db.UpdateMany(&TestModel{}, nil, map[string]interface{}{"Age": 31})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	es, err := db.History(m)
+	assert.NoError(t, err)
+	if assert.Equal(t, []string{mud.AuditInsert, mud.AuditUpdate}, kinds(es)) {
+		d, err := es[1].Diff()
+		assert.NoError(t, err)
+		assert.Contains(t, d, "Age")
+	}
+}
+
+func TestUpdateExpressions(t *testing.T) {
+	db := newSQLiteDB(t)
+	ms := []*TestModel{{Name: "Ann", Age: 30}, {Name: "Bob", Age: 40}}
+	for _, m := range ms {
+		assert.NoError(t, db.Save(m))
+	}
+
+	n, err := db.UpdateMany(&TestModel{}, nil, map[string]interface{}{"Age": mud.Incr("Age", 5)})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	n, err = db.UpdateMany(&TestModel{}, where.Equal("Name", "Bob"), map[string]interface{}{"Age": mud.Expr("Age * 2")})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	got, err := mud.FromID[TestModel](db, *ms[1].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 90, got.Age)
+
+	_, err = db.UpdateMany(&TestModel{}, nil, map[string]interface{}{"Name": mud.Incr("Age", 1)})
+	assert.Error(t, err)
+}
+
+func TestUpdate(t *testing.T) {
+	db := newSQLiteDB(t)
+	m := &TestModel{Name: "Stock", Age: 2}
+	assert.NoError(t, db.Save(m))
+
+	for i, want := range []bool{true, true, false} {
+		ok, err := db.Update(m, mud.Incr("Age", -1), where.Greater("Age", 0))
+		assert.NoError(t, err)
+		assert.Equal(t, want, ok, "update %d", i)
+	}
+	assert.Equal(t, 0, m.Age)
+	// The values read back are not reported as changes
+	assert.Empty(t, mud.Changes(m))
+
+	t.Run("set", func(t *testing.T) {
+		m.Name = "Unsaved"
+		ok, err := db.Update(m, mud.Set("Age", mud.Expr("Age + 10")), mud.Set("Name", "Set"))
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, 10, m.Age)
+		assert.Equal(t, "Set", m.Name)
+	})
+
+	t.Run("transaction", func(t *testing.T) {
+		tx, err := db.BeginTransaction()
+		assert.NoError(t, err)
+		ok, err := db.Update(m, mud.Incr("Age", 1), tx)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, 11, m.Age)
+		assert.NoError(t, db.RollbackTransaction(tx))
+		got, err := mud.FromID[TestModel](db, *m.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 10, got.Age)
+	})
+
+	tests := []struct {
+		name string
+		m    *TestModel
+		args []interface{}
+	}{
+		{name: "new model", m: &TestModel{}, args: []interface{}{mud.Incr("Age", 1)}},
+		{name: "no expressions", m: m},
+		{name: "twice", m: m, args: []interface{}{mud.Incr("Age", 1), mud.Set("Age", 1)}},
+		{name: "string increment", m: m, args: []interface{}{mud.Incr("Age", "1")}},
+		{name: "expression without field", m: m, args: []interface{}{mud.Expr("Age + 1")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := db.Update(tt.m, tt.args...)
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("empty guard", func(t *testing.T) {
+		// A guard that cannot be rendered must not match
+		ok, err := db.Update(m, mud.Set("Name", "Guarded"), where.In("Name", []string{}))
+		assert.ErrorIs(t, err, mud.ErrInvalidCondition)
+		assert.False(t, ok)
+		got, err := mud.FromID[TestModel](db, *m.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Set", got.Name)
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		assert.NoError(t, db.Remove(m))
+		ok, err := db.Update(m, mud.Incr("Age", 1))
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
@@ -103,6 +103,12 @@ func TestMakeValue(t *testing.T) {
 			want:     "'2025-04-03 15:23:00.0'", // Assuming TimeToSQL formats like this
 			wantBool: true,
 		},
+		{
+			name:     "unsigned integer value",
+			input:    uint32(42),
+			want:     "42",
+			wantBool: true,
+		},
 		{
 			name:     "unsupported type (struct)",
 			input:    struct{}{},
@@ -205,6 +211,11 @@ func TestTimeToSQL(t *testing.T) {
 			input:    time.Date(9999, 12, 31, 23, 59, 59, 999999999, time.UTC),
 			expected: "9999-12-31 23:59:59.999",
 		},
+		{
+			name:     "milliseconds with leading zeros",
+			input:    time.Date(2025, 6, 15, 12, 30, 45, 5000000, time.UTC),
+			expected: "2025-06-15 12:30:45.005",
+		},
 		{
 			name:     "nanoseconds truncation",
 			input:    time.Date(2025, 6, 15, 12, 30, 45, 123456789, time.UTC),
@@ -221,3 +232,65 @@ func TestTimeToSQL(t *testing.T) {
 		})
 	}
 }
+
+func TestSQLToTime(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		want   time.Time
+		wantOk bool
+	}{
+		{
+			name:   "whole seconds",
+			input:  "2025-04-03 15:26:27",
+			want:   time.Date(2025, 4, 3, 15, 26, 27, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "milliseconds",
+			input:  "2025-04-03 15:26:27.123",
+			want:   time.Date(2025, 4, 3, 15, 26, 27, 123000000, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "RFC3339 with zone",
+			input:  "2025-04-03T15:26:27.1234567Z",
+			want:   time.Date(2025, 4, 3, 15, 26, 27, 123456700, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "RFC3339 with offset",
+			input:  "2025-04-03T15:26:27+01:00",
+			want:   time.Date(2025, 4, 3, 14, 26, 27, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "negative offset",
+			input:  "2025-04-03 23:26:27.5-0530",
+			want:   time.Date(2025, 4, 4, 4, 56, 27, 500000000, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "invalid offset",
+			input:  "2025-04-03T15:26:27+1",
+			wantOk: false,
+		},
+		{
+			name:   "invalid",
+			input:  "not a date",
+			wantOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := utils.SQLToTime(tt.input)
+			if ok != tt.wantOk {
+				t.Fatalf("SQLToTime(%v) ok = %v, want %v", tt.input, ok, tt.wantOk)
+			}
+			if ok && !got.Equal(tt.want) {
+				t.Errorf("SQLToTime(%v) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -626,3 +626,195 @@ func TestWhereAndIn2(t *testing.T) {
 		t.Errorf("expecting '%s' got '%s'", expected[1], result)
 	}
 }
+
+func TestWhereColumn(t *testing.T) {
+	tests := []struct {
+		mgr  mud.Manager
+		name string
+		in   *where.Builder
+		out  string
+	}{
+		{mgr: &mud.MySQLManager{}, name: "MySQL Greater", in: where.Greater("ShippedDate", where.Col("OrderDate")), out: "`ShippedDate` > `OrderDate`"},
+		{mgr: &mud.MSSQLManager{}, name: "MSSQL Greater", in: where.Greater("ShippedDate", where.Col("OrderDate")), out: "[ShippedDate] > [OrderDate]"},
+		{mgr: &mud.SqliteManager{}, name: "SQLite Greater", in: where.Greater("ShippedDate", where.Col("OrderDate")), out: "\"ShippedDate\" > \"OrderDate\""},
+		{mgr: &mud.SqliteManager{}, name: "SQLite Between", in: where.Between("Age", where.Col("Min"), where.Col("Max")), out: "\"Age\" BETWEEN \"Min\" AND \"Max\""},
+		{mgr: &mud.SqliteManager{}, name: "SQLite And", in: where.Equal("Name", "Alex").AndNotEqual("Start", where.Col("End")), out: "\"Name\" = 'Alex' AND \"Start\" <> \"End\""},
+		{mgr: &mud.SqliteManager{}, name: "SQLite Empty", in: where.Equal("Name", where.Col("")), out: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if r := tt.in.String(tt.mgr.Operators()); r != tt.out {
+				t.Errorf("expecting '%s' got '%s'", tt.out, r)
+			}
+		})
+	}
+}
+
+func TestWhereRaw(t *testing.T) {
+	tests := []struct {
+		mgr  mud.Manager
+		name string
+		in   *where.Builder
+		out  string
+	}{
+		{mgr: &mud.MySQLManager{}, name: "MySQL Raw", in: where.Raw("LOWER(?) = ?", where.Col("Email"), "alex@example.com"), out: "(LOWER(`Email`) = 'alex@example.com')"},
+		{mgr: &mud.MSSQLManager{}, name: "MSSQL Raw", in: where.Raw("LOWER(?) = ?", where.Col("Email"), "alex@example.com"), out: "(LOWER([Email]) = 'alex@example.com')"},
+		{mgr: &mud.SqliteManager{}, name: "SQLite Raw", in: where.Raw("LOWER(?) = ?", where.Col("Email"), "alex@example.com"), out: "(LOWER(\"Email\") = 'alex@example.com')"},
+		{mgr: &mud.SqliteManager{}, name: "SQLite And", in: where.Equal("Age", 12).AndRaw("? % 2 = 0", where.Col("Score")), out: "\"Age\" = 12 AND (\"Score\" % 2 = 0)"},
+		{mgr: &mud.SqliteManager{}, name: "SQLite Or", in: where.Raw("Age > ?", 60).OrRaw("Age < ?", 18), out: "(Age > 60) OR (Age < 18)"},
+		{mgr: &mud.SqliteManager{}, name: "SQLite In", in: where.Raw("Age IN (?)", []int{1, 2, 3}), out: "(Age IN (1,2,3))"},
+		{mgr: &mud.SqliteManager{}, name: "SQLite Quoted", in: where.Raw("Name = '?' OR Name = ?", "it's"), out: "(Name = '?' OR Name = 'it''s')"},
+		{mgr: &mud.SqliteManager{}, name: "SQLite Missing Argument", in: where.Raw("Age > ?"), out: ""},
+		{mgr: &mud.SqliteManager{}, name: "SQLite Extra Argument", in: where.Raw("Age > 1", 2), out: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if r := tt.in.String(tt.mgr.Operators()); r != tt.out {
+				t.Errorf("expecting '%s' got '%s'", tt.out, r)
+			}
+		})
+	}
+}
+
+func TestWhereRawQuery(t *testing.T) {
+	db := newSQLiteDB(t)
+	for _, m := range []*TestModel{{Name: "Al", Age: 2}, {Name: "Alex", Age: 4}, {Name: "Alexander", Age: 5}} {
+		if err := db.Save(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ms, err := mud.Fetch[TestModel](db, where.Raw("LENGTH(?) = ?", where.Col("Name"), where.Col("Age")).OrRaw("UPPER(?) = ?", where.Col("Name"), "ALEXANDER"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 3 {
+		t.Errorf("expecting 3 models got %d", len(ms))
+	}
+	if n := db.Count(&TestModel{}, where.Less("Age", where.Col("Age"))); n != 0 {
+		t.Errorf("expecting 0 models got %d", n)
+	}
+}
+
+func TestWhereEscapedLike(t *testing.T) {
+	tests := []struct {
+		mgr  mud.Manager
+		name string
+		in   *where.Builder
+		out  string
+	}{
+		{mgr: &mud.SqliteManager{}, name: "SQLite Contains", in: where.Contains("Name", "50%"), out: "\"Name\" LIKE '%50\\%%' ESCAPE '\\'"},
+		{mgr: &mud.SqliteManager{}, name: "SQLite Starts With", in: where.StartsWith("Name", "a_b"), out: "\"Name\" LIKE 'a\\_b%' ESCAPE '\\'"},
+		{mgr: &mud.SqliteManager{}, name: "SQLite Not Ends With", in: where.NotEndsWith("Name", "c:\\"), out: "\"Name\" NOT LIKE '%c:\\\\' ESCAPE '\\'"},
+		{mgr: &mud.MySQLManager{}, name: "MySQL Contains", in: where.Contains("Name", "100%!"), out: "`Name` LIKE '%100!%!!%' ESCAPE '!'"},
+		{mgr: &mud.MySQLManager{}, name: "MySQL Or Not Contains", in: where.Equal("Age", 1).OrNotContains("Name", "_"), out: "`Age` = 1 OR `Name` NOT LIKE '%!_%' ESCAPE '!'"},
+		{mgr: &mud.MSSQLManager{}, name: "MSSQL Contains", in: where.Contains("Name", "[a]"), out: "[Name] LIKE '%\\[a]%' ESCAPE '\\'"},
+		{mgr: &mud.MSSQLManager{}, name: "MSSQL Like", in: where.Like("Name", "[a]%"), out: "[Name] LIKE '[a]%'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if r := tt.in.String(tt.mgr.Operators()); r != tt.out {
+				t.Errorf("expecting '%s' got '%s'", tt.out, r)
+			}
+		})
+	}
+}
+
+func TestWhereDialectValues(t *testing.T) {
+	tests := []struct {
+		mgr  mud.Manager
+		name string
+		in   *where.Builder
+		out  string
+	}{
+		// The backslash must not escape the quote, which would end the literal early
+		{mgr: &mud.MySQLManager{}, name: "MySQL Backslash Quote", in: where.Equal("Name", `x\' OR 1=1 -- `), out: "`Name` = 'x\\\\'' OR 1=1 -- '"},
+		{mgr: &mud.MySQLManager{}, name: "MySQL Raw", in: where.Raw("`Name` = ?", `a\b`), out: "(`Name` = 'a\\\\b')"},
+		{mgr: &mud.MySQLManager{}, name: "MySQL Contains", in: where.Contains("Name", `c:\`), out: "`Name` LIKE '%c:\\\\%'"},
+		{mgr: &mud.MSSQLManager{}, name: "MSSQL Unicode", in: where.Equal("Name", "Ελένη").OrIn("City", []string{"Αθήνα", "Rome"}), out: "[Name] = N'Ελένη' OR [City] IN (N'Αθήνα',N'Rome')"},
+		{mgr: &mud.SqliteManager{}, name: "SQLite Backslash", in: where.Equal("Name", `x\'`), out: `"Name" = 'x\'''`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if r := tt.in.Render(tt.mgr.Operators(), tt.mgr.MakeValue); r != tt.out {
+				t.Errorf("expecting '%s' got '%s'", tt.out, r)
+			}
+		})
+	}
+
+	// Criteria render their conditions with the literals of the dialect
+	c := mud.Criteria{Where: where.Equal("Name", `x\'`), IncDeleted: true}
+	if r := c.WhereString(&mud.MySQLManager{}); r != " WHERE `Name` = 'x\\\\'''" {
+		t.Errorf("unexpected where clause '%s'", r)
+	}
+}
+
+func TestWhereOperatorCount(t *testing.T) {
+	ops := (&mud.SqliteManager{}).Operators()
+	tests := []struct {
+		name string
+		in   *where.Builder
+		ops  []string
+		out  string
+	}{
+		{name: "Complete", in: where.Equal("Name", "a"), ops: ops, out: `"Name" = 'a'`},
+		// An incomplete operator list would select the wrong NOT forms
+		{name: "Short", in: where.Equal("Name", "a"), ops: ops[:len(ops)-2], out: ""},
+		{name: "Long", in: where.NotEqual("Name", "a"), ops: append(ops, "%s = %s", "%s <> %s"), out: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.in.String(tt.ops); got != tt.out {
+				t.Errorf("expecting %q got %q", tt.out, got)
+			}
+		})
+	}
+}
+
+func TestWhereCaseInsensitive(t *testing.T) {
+	tests := []struct {
+		mgr  mud.Manager
+		name string
+		in   *where.Builder
+		out  string
+	}{
+		{mgr: &mud.SqliteManager{}, name: "SQLite Equal", in: where.IEqual("Email", "Alex@Example.com"), out: "LOWER(\"Email\") = LOWER('Alex@Example.com')"},
+		{mgr: &mud.MySQLManager{}, name: "MySQL Not Equal", in: where.INotEqual("Email", "A"), out: "LOWER(`Email`) <> LOWER('A')"},
+		{mgr: &mud.MSSQLManager{}, name: "MSSQL Contains", in: where.IContains("Name", "Al"), out: "LOWER([Name]) LIKE LOWER('%Al%') ESCAPE '\\'"},
+		{mgr: &mud.MySQLManager{}, name: "MySQL Not Contains", in: where.INotContains("Name", "5%"), out: "LOWER(`Name`) NOT LIKE LOWER('%5!%%') ESCAPE '!'"},
+		{mgr: &mud.SqliteManager{}, name: "SQLite And Or", in: where.IEqual("A", "x").AndIContains("B", "y").OrINotEqual("C", "z"), out: "LOWER(\"A\") = LOWER('x') AND LOWER(\"B\") LIKE LOWER('%y%') ESCAPE '\\' OR LOWER(\"C\") <> LOWER('z')"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if r := tt.in.String(tt.mgr.Operators()); r != tt.out {
+				t.Errorf("expecting '%s' got '%s'", tt.out, r)
+			}
+		})
+	}
+}
+
+func TestWhereMatchingQuery(t *testing.T) {
+	db := newSQLiteDB(t)
+	for _, n := range []string{"50% off", "500 off", "a_b", "axb", "Alex"} {
+		if err := db.Save(&TestModel{Name: n}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name string
+		in   *where.Builder
+		want int
+	}{
+		{name: "percent", in: where.Contains("Name", "50%"), want: 1},
+		{name: "underscore", in: where.StartsWith("Name", "a_"), want: 1},
+		{name: "equal", in: where.IEqual("Name", "ALEX"), want: 1},
+		{name: "contains", in: where.IContains("Name", "LE"), want: 1},
+		{name: "not contains", in: where.INotContains("Name", "OFF"), want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if n := db.Count(&TestModel{}, tt.in); n != tt.want {
+				t.Errorf("expecting %d got %d", tt.want, n)
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+
+// Package mud provides set-based and atomic updates of models.
+package mud
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/markoxley/mud/utils"
+)
+
+// Expression is an assignment made by an update. The value is either a
+// literal, or computed by the database from the current values of the row, so
+// that concurrent updates do not overwrite each other.
+type Expression struct {
+	// field is the field assigned
+	field string
+	// expr is the SQL expression assigned, for Expr
+	expr string
+	// value is the literal assigned, or the amount added for Incr
+	value interface{}
+	// incr indicates value is added to the current value of the field
+	incr bool
+}
+
+// Expr returns an expression evaluated by the database, such as "Score * 2".
+// The SQL is used as given. In UpdateMany it is assigned to the field it is
+// keyed by, and in Update it is assigned with Set.
+// @param sql
+// @return Expression
+func Expr(sql string) Expression {
+	return Expression{expr: sql}
+}
+
+// Incr returns an expression that adds delta, which may be negative, to the
+// current value of the field
+// @param field
+// @param delta
+// @return Expression
+func Incr(field string, delta interface{}) Expression {
+	return Expression{field: field, value: delta, incr: true}
+}
+
+// Set returns an expression that assigns the value to the field. The value
+// may be a literal, nil for null, or an Expression returned by Expr
+// @param field
+// @param value
+// @return Expression
+func Set(field string, value interface{}) Expression {
+	if e, ok := value.(Expression); ok {
+		if e.field == "" {
+			e.field = field
+		}
+		return e
+	}
+	return Expression{field: field, value: value}
+}
+
+// UpdateMany sets the fields of every model of the specified type that matches
+// the criteria with a single UPDATE statement, without loading the models.
+// The values are keyed by field name, and a nil value sets the field to null.
+// Values may also be expressions returned by Expr or Incr. LastUpdate is set
+// on every row changed. Removed models, and the models of other tenants, are
+// never changed. Returns the number of models updated. ErrInvalidCondition is
+// returned if the criteria has a condition that cannot be rendered
+// @param m
+// @param criteria
+// @param values
+// @param tx
+// @return int
+// @return error
+func (db *DB) UpdateMany(m Modeller, criteria interface{}, values map[string]interface{}, tx ...*sql.Tx) (int, error) {
+	names := make([]string, 0, len(values))
+	for k := range values {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	set := make([]Expression, 0, len(names))
+	for _, k := range names {
+		e := Set(k, values[k])
+		if e.field != k {
+			return 0, fmt.Errorf("expression for %s assigned to %s", e.field, k)
+		}
+		set = append(set, e)
+	}
+
+	c, err := db.getCriteria([]interface{}{criteria})
+	if err != nil {
+		return 0, err
+	}
+	flds, n, err := db.tableTest(m)
+	if err != nil {
+		return 0, err
+	}
+	sets, _, err := db.setClauses(flds, set)
+	if err != nil {
+		return 0, err
+	}
+	rc, err := db.removeCriteria(m, c)
+	if err != nil {
+		return 0, err
+	}
+	// Without the condition every row would be updated
+	if rc.invalidWhere(db.mgr) {
+		return 0, ErrInvalidCondition
+	}
+	r, err := db.updateWhere(m, rc, Operation{
+		Name:      OpUpdate,
+		Table:     n,
+		Statement: fmt.Sprintf("UPDATE %s SET %s %s", db.mgr.IdentityString(n), strings.Join(sets, ", "), strings.TrimSpace(rc.WhereString(db.mgr))),
+	}, tx...)
+	return int(r), err
+}
+
+// Update applies the expressions to the stored model with a single UPDATE
+// statement, such as
+//
+//	db.Update(product, mud.Incr("Stock", -1), where.Greater("Stock", 0))
+//
+// The arguments are the expressions, along with an optional guard, given as
+// any criteria accepted by Fetch, and an optional transaction. The row is only
+// updated if it still matches the guard. Returns true if the row was updated,
+// in which case the assigned fields and LastUpdate are read back into the model.
+// Other changes to the model are not saved. ErrInvalidCondition is returned if
+// the guard cannot be rendered
+// @param m
+// @param args
+// @return bool
+// @return error
+func (db *DB) Update(m Modeller, args ...interface{}) (bool, error) {
+	if m.IsNew() {
+		return false, errors.New("model has not been saved")
+	}
+	var (
+		set   []Expression
+		tx    []*sql.Tx
+		guard []interface{}
+	)
+	for _, a := range args {
+		switch v := a.(type) {
+		case Expression:
+			set = append(set, v)
+		case *sql.Tx:
+			tx = append(tx, v)
+		default:
+			guard = append(guard, v)
+		}
+	}
+	if len(guard) > 1 || len(tx) > 1 {
+		return false, errors.New("invalid update arguments")
+	}
+	c, err := db.getCriteria(guard)
+	if err != nil {
+		return false, err
+	}
+
+	flds, n, err := db.tableTest(m)
+	if err != nil {
+		return false, err
+	}
+	if err := db.tenantModel(m, flds); err != nil {
+		return false, err
+	}
+	sets, assigned, err := db.setClauses(flds, set)
+	if err != nil {
+		return false, err
+	}
+	id, _ := db.mgr.MakeValue(*m.GetID())
+	rc, err := db.removeCriteria(m, c.Unscoped().withWhere(fmt.Sprintf("%s = %s", db.mgr.IdentityString("ID"), id)))
+	if err != nil {
+		return false, err
+	}
+	// A guard that is left out would always match
+	if rc.invalidWhere(db.mgr) {
+		return false, ErrInvalidCondition
+	}
+	r, err := db.updateWhere(m, rc, Operation{
+		Name:      OpUpdate,
+		Table:     n,
+		Statement: fmt.Sprintf("UPDATE %s SET %s %s", db.mgr.IdentityString(n), strings.Join(sets, ", "), strings.TrimSpace(rc.WhereString(db.mgr))),
+	}, tx...)
+	if err != nil {
+		return false, err
+	}
+	if r == 0 {
+		return false, db.tenantWritten(m, flds, r, tx...)
+	}
+
+	for _, f := range flds {
+		if f.name == "LastUpdate" {
+			assigned = append(assigned, f)
+		}
+	}
+	if err := db.readBack(m, assigned, tx...); err != nil {
+		return true, err
+	}
+	// The values read back may still be rolled back with the caller's transaction
+	if len(tx) == 0 {
+		updateSnapshot(m, assigned)
+	} else if s, ok := m.(snapshotter); ok {
+		s.setSnapshot(nil)
+	}
+	return true, nil
+}
+
+// updateWhere executes an UPDATE statement of the models matching the criteria,
+// recording the changes if the model is audited. Returns the number of rows updated
+// @param m
+// @param c
+// @param op
+// @param tx
+// @return int64
+// @return error
+func (db *DB) updateWhere(m Modeller, c *Criteria, op Operation, tx ...*sql.Tx) (int64, error) {
+	if db.audited(m) {
+		return db.execAudited(m, c, AuditUpdate, op, tx...)
+	}
+	res, err := db.execute(op, tx...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// setClauses returns the assignments of an UPDATE statement for the expressions,
+// followed by the assignment of LastUpdate, along with the fields assigned
+// @param flds
+// @param set
+// @return []string
+// @return []field
+// @return error
+func (db *DB) setClauses(flds []field, set []Expression) ([]string, []field, error) {
+	if len(set) == 0 {
+		return nil, nil, errors.New("no values to update")
+	}
+	byName := make(map[string]field, len(flds))
+	for _, f := range flds {
+		byName[f.name] = f
+	}
+
+	sets := make([]string, 0, len(set)+1)
+	assigned := make([]field, 0, len(set))
+	for _, e := range set {
+		f, ok := byName[e.field]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown field: %s", e.field)
+		}
+		if f.tenant {
+			return nil, nil, ErrCrossTenant
+		}
+		if !f.tracked() {
+			return nil, nil, fmt.Errorf("field cannot be updated: %s", e.field)
+		}
+		for _, a := range assigned {
+			if a.name == f.name {
+				return nil, nil, fmt.Errorf("field assigned more than once: %s", e.field)
+			}
+		}
+		vl, err := db.expressionValue(f, e)
+		if err != nil {
+			return nil, nil, err
+		}
+		sets = append(sets, fmt.Sprintf("%s = %s", db.mgr.IdentityString(f.name), vl))
+		assigned = append(assigned, f)
+	}
+	sets = append(sets, fmt.Sprintf("%s = '%v'", db.mgr.IdentityString("LastUpdate"), utils.TimeToSQL(time.Now())))
+	return sets, assigned, nil
+}
+
+// expressionValue returns the SQL assigned to the field by the expression
+// @param f
+// @param e
+// @return string
+// @return error
+func (db *DB) expressionValue(f field, e Expression) (string, error) {
+	if e.expr != "" {
+		return e.expr, nil
+	}
+	if rv := reflect.ValueOf(e.value); e.value == nil || (rv.Kind() == reflect.Pointer && rv.IsNil()) {
+		if e.incr || !f.allowNull {
+			return "", fmt.Errorf("field cannot be null: %s", f.name)
+		}
+		return "null", nil
+	}
+	if e.incr {
+		switch e.value.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		default:
+			return "", fmt.Errorf("invalid increment for field %s: %v", f.name, e.value)
+		}
+	}
+	vl, ok := db.mgr.MakeValue(e.value)
+	if !ok {
+		return "", fmt.Errorf("invalid value for field %s: %v", f.name, e.value)
+	}
+	if !e.incr {
+		return vl, nil
+	}
+	if strings.HasPrefix(vl, "-") {
+		return fmt.Sprintf("%s - %s", db.mgr.IdentityString(f.name), vl[1:]), nil
+	}
+	return fmt.Sprintf("%s + %s", db.mgr.IdentityString(f.name), vl), nil
+}
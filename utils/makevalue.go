@@ -25,7 +25,7 @@ func MakeValue(value interface{}) (string, bool) {
 	case float64:
 		// Convert float64 to SQL-compatible string
 		return fmt.Sprintf("%f", v), true
-	case int, int8, int16, int32, int64:
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
 		// Convert integer to SQL-compatible string
 		return fmt.Sprintf("%d", v), true
 	case bool:
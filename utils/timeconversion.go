@@ -26,9 +26,10 @@ func TimeToSQL(t time.Time) string {
 	mn = t.Minute()
 	s = t.Second()
 	ns = t.Nanosecond()
-	nss := strconv.FormatInt(int64(ns), 10)
-	if len(nss) > 3 {
-		nss = nss[:3]
+	// Milliseconds keep their leading zeros, so that 5ms is not written as 500ms
+	nss := "0"
+	if ns > 0 {
+		nss = fmt.Sprintf("%03d", ns/int(time.Millisecond))
 	}
 
 	// Format the time components into SQL string
@@ -44,6 +45,7 @@ func TimeToSQL(t time.Time) string {
 func SQLToTime(st string) (*time.Time, bool) {
 	sep := " "
 	var y, m, d, h, mn, s, ns int
+	var off time.Duration
 	var e error
 
 	// Check for alternative separator
@@ -79,7 +81,16 @@ func SQLToTime(st string) (*time.Time, bool) {
 
 	// Parse time part
 	if len(dt) > 1 {
-		timeParts := strings.Split(dt[1], ":")
+		// Times are returned in UTC, so any zone offset is applied
+		tp := strings.TrimSuffix(dt[1], "Z")
+		if i := strings.IndexAny(tp, "+-"); i >= 0 {
+			var ok bool
+			if off, ok = zoneOffset(tp[i:]); !ok {
+				return nil, false
+			}
+			tp = tp[:i]
+		}
+		timeParts := strings.Split(tp, ":")
 		if len(timeParts) != 3 {
 			return nil, false
 		}
@@ -91,22 +102,52 @@ func SQLToTime(st string) (*time.Time, bool) {
 		if e != nil {
 			return nil, false
 		}
-		s, e = strconv.Atoi(timeParts[2])
+		nsParts := strings.Split(timeParts[2], ".")
+		s, e = strconv.Atoi(nsParts[0])
 		if e != nil {
 			return nil, false
 		}
-		if strings.Contains(timeParts[2], ".") {
-			nsParts := strings.Split(timeParts[2], ".")
-			if len(nsParts) > 1 {
-				ns, e = strconv.Atoi(nsParts[1])
-				if e != nil {
-					return nil, false
-				}
+		if len(nsParts) > 1 {
+			// Scale the fraction to nanoseconds
+			frac := nsParts[1]
+			if len(frac) > 9 {
+				frac = frac[:9]
+			}
+			frac += strings.Repeat("0", 9-len(frac))
+			ns, e = strconv.Atoi(frac)
+			if e != nil {
+				return nil, false
 			}
 		}
 	}
 
 	// Create and return time.Time object
-	t := time.Date(y, time.Month(m), d, h, mn, s, ns, time.UTC)
+	t := time.Date(y, time.Month(m), d, h, mn, s, ns, time.UTC).Add(-off)
 	return &t, true
 }
+
+// zoneOffset parses a zone offset in the form +HH:MM, +HHMM or +HH
+//
+// @param z The zone offset, starting with its sign
+// @return The offset from UTC, and a boolean indicating success
+func zoneOffset(z string) (time.Duration, bool) {
+	sign := time.Duration(1)
+	if z[0] == '-' {
+		sign = -1
+	}
+	z = strings.ReplaceAll(z[1:], ":", "")
+	if len(z) != 2 && len(z) != 4 {
+		return 0, false
+	}
+	h, e := strconv.Atoi(z[:2])
+	if e != nil {
+		return 0, false
+	}
+	mn := 0
+	if len(z) == 4 {
+		if mn, e = strconv.Atoi(z[2:]); e != nil {
+			return 0, false
+		}
+	}
+	return sign * (time.Duration(h)*time.Hour + time.Duration(mn)*time.Minute), true
+}
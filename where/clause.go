@@ -7,8 +7,6 @@ package where
 import (
 	"fmt"
 	"strings"
-
-	"github.com/markoxley/mud/utils"
 )
 
 // clause represents a single SQL WHERE clause condition
@@ -20,28 +18,73 @@ type clause struct {
 	not         bool
 	op          operator
 	values      []interface{}
+	// wildStart and wildEnd add wildcards around escaped LIKE patterns
+	wildStart bool
+	wildEnd   bool
 }
 
-// String generates the SQL clause string representation
+// render generates the SQL clause string representation
 // This method implements the clauser interface
 //
 // @receiver c The clause instance
 // @param operators List of operator strings to use for generating the clause
+// @param value Function that converts a value to a SQL literal
 // @return SQL clause string or empty string if invalid
-func (c clause) String(operators []string) string {
+func (c clause) render(operators []string, value func(interface{}) (string, bool)) string {
+	// Each operator is followed by its NOT form, half the list further on
+	if len(operators) != 2*int(opCount) {
+		return ""
+	}
+	values := c.values
+	op := c.op
+	if op == opLikeEscape || op == opILike {
+		v, ok := values[0].(string)
+		if !ok {
+			return ""
+		}
+		p := escapeLike(v, likeEscape(operators))
+		// The ESCAPE clause is only needed if the text has characters to escape
+		if op == opLikeEscape && p == v {
+			op = opLike
+		}
+		if c.wildStart {
+			p = "%" + p
+		}
+		if c.wildEnd {
+			p += "%"
+		}
+		values = []interface{}{p}
+	}
+
+	if op == opMatch {
+		v, ok := values[0].(string)
+		if !ok {
+			return ""
+		}
+		// A search without words matches nothing, rather than everything
+		q := strings.Join(strings.Fields(v), " ")
+		if q == "" {
+			if c.not {
+				return "1 = 1"
+			}
+			return "1 = 0"
+		}
+		values = []interface{}{q}
+	}
+
 	// Calculate operator index, adjusting for NOT conditions
-	opCode := int(c.op)
+	opCode := int(op)
 	if c.not {
-		opCode += len(operators) / 2
+		opCode += int(opCount)
 	}
 
 	// Determine number of fields based on operator type
 	fieldCount := 1
-	switch c.op {
+	switch op {
 	case opBetween:
 		fieldCount = 2
 	case opIn:
-		fieldCount = len(c.values)
+		fieldCount = len(values)
 		if fieldCount == 0 {
 			return ""
 		}
@@ -50,14 +93,14 @@ func (c clause) String(operators []string) string {
 	}
 
 	// Validate values count
-	if len(c.values) < fieldCount {
+	if len(values) < fieldCount {
 		return ""
 	}
 
 	// Convert values to strings
 	vls := make([]string, fieldCount)
 	for i := 0; i < fieldCount; i++ {
-		f, ok := utils.MakeValue(c.values[i])
+		f, ok := makeValue(values[i], operators, value)
 		if !ok {
 			return ""
 		}
@@ -65,13 +108,15 @@ func (c clause) String(operators []string) string {
 	}
 
 	// Construct and return the SQL clause
-	switch c.op {
+	switch op {
 	case opIn:
 		return fmt.Sprintf(operators[opCode], c.field, strings.Join(vls, ","))
 	case opBetween:
 		v1 := vls[0]
 		v2 := vls[1]
-		if v1 > v2 {
+		_, col1 := values[0].(Column)
+		_, col2 := values[1].(Column)
+		if v1 > v2 && !col1 && !col2 {
 			v1 = vls[1]
 			v2 = vls[0]
 		}
@@ -109,3 +154,61 @@ func newClause(c conjunction, f string, o operator, n bool, v ...interface{}) *c
 		values:      v,
 	}
 }
+
+// newPattern creates a new clause that matches text with LIKE, escaping the
+// wildcard characters in the text
+//
+// @param c The logical conjunction for combining clauses
+// @param f The field name for the clause
+// @param o The operator for the clause, opLikeEscape or opILike
+// @param n Whether the clause is negated
+// @param v The text to match
+// @param start Whether the text may be preceded by other text
+// @param end Whether the text may be followed by other text
+// @return A new clause instance
+func newPattern(c conjunction, f string, o operator, n bool, v string, start, end bool) *clause {
+	p := newClause(c, f, o, n, v)
+	p.wildStart = start
+	p.wildEnd = end
+	return p
+}
+
+// likeEscape returns the escape character of the escaped LIKE operator format,
+// which ends with an ESCAPE clause. Returns zero if there is no ESCAPE clause
+//
+// @param operators List of operator strings used to generate clauses
+// @return The escape character
+func likeEscape(operators []string) rune {
+	if len(operators) <= int(opLikeEscape) {
+		return 0
+	}
+	f := operators[opLikeEscape]
+	i := strings.LastIndex(f, "ESCAPE '")
+	if i < 0 {
+		return 0
+	}
+	for _, r := range f[i+len("ESCAPE '"):] {
+		return r
+	}
+	return 0
+}
+
+// escapeLike escapes the LIKE wildcards in the text, along with the opening
+// bracket of a SQL Server character range and the escape character itself
+//
+// @param v The text to escape
+// @param esc The escape character, or zero if the text cannot be escaped
+// @return The escaped text
+func escapeLike(v string, esc rune) string {
+	if esc == 0 {
+		return v
+	}
+	var sb strings.Builder
+	for _, r := range v {
+		if r == '%' || r == '_' || r == '[' || r == esc {
+			sb.WriteRune(esc)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -8,12 +8,13 @@ package where
 // Implementations of this interface are responsible for generating SQL clauses
 // and managing logical conjunctions between conditions
 type clauser interface {
-	// String returns the SQL clause string representation
-	// The provided field names are used to construct the clause
+	// render returns the SQL clause string representation
+	// The provided operator formats are used to construct the clause
 	//
-	// @param fields List of field names to use in the clause
+	// @param operators List of operator strings to use in the clause
+	// @param value Function that converts a value to a SQL literal
 	// @return SQL clause string
-	String([]string) string
+	render(operators []string, value func(interface{}) (string, bool)) string
 
 	// getConjunction returns the logical conjunction used to combine this clause
 	// with other clauses in a WHERE condition
@@ -0,0 +1,88 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+// Package where provides functionality for building SQL WHERE clauses
+package where
+
+import (
+	"strings"
+
+	"github.com/markoxley/mud/utils"
+)
+
+// Column is a value that refers to another column of the row, rather than a literal
+type Column struct {
+	name string
+}
+
+// Col returns a value that compares a field with another column, such as
+//
+//	where.Greater("ShippedDate", where.Col("OrderDate"))
+//
+// The column may be qualified by its table, as in "Order.CustomerID", to refer
+// to the outer query from within a subquery
+//
+// @param name
+// @return Column
+func Col(name string) Column {
+	return Column{name: name}
+}
+
+// identity returns a function that quotes identifiers in the same way as the
+// operator formats, which begin with the quoted field
+//
+// @param operators List of operator strings used to generate clauses
+// @return Function that quotes an identifier
+func identity(operators []string) func(string) string {
+	if len(operators) == 0 {
+		return func(s string) string { return s }
+	}
+	f := operators[opEqual]
+	i := strings.Index(f, "%s")
+	if i < 0 {
+		return func(s string) string { return s }
+	}
+	open, close := f[:i], f[i+2:]
+	if j := strings.IndexByte(close, ' '); j >= 0 {
+		close = close[:j]
+	}
+	return func(s string) string { return open + s + close }
+}
+
+// literal returns the function that converts values to SQL literals, which is
+// the generic conversion when the dialect does not provide one
+//
+// @param value Function that converts a value to a SQL literal, or nil
+// @return Function that converts a value to a SQL literal
+func literal(value func(interface{}) (string, bool)) func(interface{}) (string, bool) {
+	if value == nil {
+		return utils.MakeValue
+	}
+	return value
+}
+
+// makeValue converts a value to SQL, quoting columns as identifiers, rendering
+// subqueries, and converting everything else to a literal
+//
+// @param v The value to convert
+// @param operators List of operator strings used to generate clauses
+// @param value Function that converts a value to a SQL literal, or nil
+// @return SQL string and a boolean indicating success
+func makeValue(v interface{}, operators []string, value func(interface{}) (string, bool)) (string, bool) {
+	if c, ok := v.(Column); ok {
+		if c.name == "" {
+			return "", false
+		}
+		quote := identity(operators)
+		parts := strings.Split(c.name, ".")
+		for i, p := range parts {
+			parts[i] = quote(p)
+		}
+		return strings.Join(parts, "."), true
+	}
+	if q, ok := v.(Query); ok {
+		s := q.Select(operators, identity(operators), literal(value))
+		return s, s != ""
+	}
+	return literal(value)(v)
+}
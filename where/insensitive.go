@@ -0,0 +1,112 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+// Package where provides functionality for building SQL WHERE clauses
+package where
+
+// IEqual creates a new Builder with the first clause being a case-insensitive
+// equal clause. Both sides are lower cased by the database, so the result
+// does not depend on the collation of the column
+// @param field
+// @param value
+// @return *Builder
+func IEqual(field string, value string) *Builder {
+	n := NewBuilder(conAnd)
+	n.children = append(n.children, newClause(conAnd, field, opIEqual, false, value))
+	return n
+}
+
+// INotEqual creates a new Builder with the first clause being a case-insensitive not equal clause
+// @param field
+// @param value
+// @return *Builder
+func INotEqual(field string, value string) *Builder {
+	n := NewBuilder(conAnd)
+	n.children = append(n.children, newClause(conAnd, field, opIEqual, true, value))
+	return n
+}
+
+// IContains creates a new Builder with the first clause being a case-insensitive
+// contains clause. Wildcards in the value are matched literally
+// @param field
+// @param value
+// @return *Builder
+func IContains(field string, value string) *Builder {
+	n := NewBuilder(conAnd)
+	n.children = append(n.children, newPattern(conAnd, field, opILike, false, value, true, true))
+	return n
+}
+
+// INotContains creates a new Builder with the first clause being a case-insensitive not contains clause
+// @param field
+// @param value
+// @return *Builder
+func INotContains(field string, value string) *Builder {
+	n := NewBuilder(conAnd)
+	n.children = append(n.children, newPattern(conAnd, field, opILike, true, value, true, true))
+	return n
+}
+
+// AndIEqual adds a case-insensitive equal clause to the clause with an AND conjunction
+// @receiver c
+// @param field
+// @param value
+// @return *Builder
+func (c *Builder) AndIEqual(field string, value string) *Builder {
+	c.children = append(c.children, newClause(conAnd, field, opIEqual, false, value))
+	return c
+}
+
+// AndINotEqual adds a case-insensitive not equal clause to the clause with an AND conjunction
+// @receiver c
+// @param field
+// @param value
+// @return *Builder
+func (c *Builder) AndINotEqual(field string, value string) *Builder {
+	c.children = append(c.children, newClause(conAnd, field, opIEqual, true, value))
+	return c
+}
+
+// AndIContains adds a case-insensitive contains clause to the clause with an AND conjunction
+// @receiver c
+// @param field
+// @param value
+// @return *Builder
+func (c *Builder) AndIContains(field string, value string) *Builder {
+	c.children = append(c.children, newPattern(conAnd, field, opILike, false, value, true, true))
+	return c
+}
+
+// AndINotContains adds a case-insensitive not contains clause to the clause with an AND conjunction
+// @receiver c
+// @param field
+// @param value
+// @return *Builder
+func (c *Builder) AndINotContains(field string, value string) *Builder {
+	c.children = append(c.children, newPattern(conAnd, field, opILike, true, value, true, true))
+	return c
+}
+
+// OrIEqual adds a case-insensitive equal clause to the clause with an OR conjunction
+func (c *Builder) OrIEqual(field string, value string) *Builder {
+	c.children = append(c.children, newClause(conOr, field, opIEqual, false, value))
+	return c
+}
+
+// OrINotEqual adds a case-insensitive not equal clause to the clause with an OR conjunction
+func (c *Builder) OrINotEqual(field string, value string) *Builder {
+	c.children = append(c.children, newClause(conOr, field, opIEqual, true, value))
+	return c
+}
+
+// OrIContains adds a case-insensitive contains clause to the clause with an OR conjunction
+func (c *Builder) OrIContains(field string, value string) *Builder {
+	c.children = append(c.children, newPattern(conOr, field, opILike, false, value, true, true))
+	return c
+}
+
+// OrINotContains adds a case-insensitive not contains clause to the clause with an OR conjunction
+func (c *Builder) OrINotContains(field string, value string) *Builder {
+	c.children = append(c.children, newPattern(conOr, field, opILike, true, value, true, true))
+	return c
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+// Package where provides functionality for building SQL WHERE clauses
+package where
+
+// Match creates a new Builder with the first clause being a full-text search
+// of the field, which must be tagged fulltext. The query is natural language
+// text, and rows matching any of its words are found, using the full-text
+// index of the database
+// @param field
+// @param query
+// @return *Builder
+func Match(field string, query string) *Builder {
+	n := NewBuilder(conAnd)
+	n.children = append(n.children, newClause(conAnd, field, opMatch, false, query))
+	return n
+}
+
+// NotMatch creates a new Builder with the first clause being a negated full-text search
+// @param field
+// @param query
+// @return *Builder
+func NotMatch(field string, query string) *Builder {
+	n := NewBuilder(conAnd)
+	n.children = append(n.children, newClause(conAnd, field, opMatch, true, query))
+	return n
+}
+
+// AndMatch adds a full-text search clause to the clause with an AND conjunction
+// @receiver c
+// @param field
+// @param query
+// @return *Builder
+func (c *Builder) AndMatch(field string, query string) *Builder {
+	c.children = append(c.children, newClause(conAnd, field, opMatch, false, query))
+	return c
+}
+
+// AndNotMatch adds a negated full-text search clause to the clause with an AND conjunction
+// @receiver c
+// @param field
+// @param query
+// @return *Builder
+func (c *Builder) AndNotMatch(field string, query string) *Builder {
+	c.children = append(c.children, newClause(conAnd, field, opMatch, true, query))
+	return c
+}
+
+// OrMatch adds a full-text search clause to the clause with an OR conjunction
+func (c *Builder) OrMatch(field string, query string) *Builder {
+	c.children = append(c.children, newClause(conOr, field, opMatch, false, query))
+	return c
+}
+
+// OrNotMatch adds a negated full-text search clause to the clause with an OR conjunction
+func (c *Builder) OrNotMatch(field string, query string) *Builder {
+	c.children = append(c.children, newClause(conOr, field, opMatch, true, query))
+	return c
+}
@@ -9,24 +9,32 @@ type operator uint8
 
 // Operator constants define the supported comparison operations
 const (
-	opEqual   operator = iota // Equal (=) comparison
-	opGreater               // Greater than (>) comparison
-	opLess                  // Less than (<) comparison
-	opLike                  // LIKE pattern matching
-	opIn                    // IN clause for multiple values
-	opBetween               // BETWEEN range comparison
-	opIsNull                // IS NULL check
+	opEqual      operator = iota // Equal (=) comparison
+	opGreater                    // Greater than (>) comparison
+	opLess                       // Less than (<) comparison
+	opLike                       // LIKE pattern matching
+	opIn                         // IN clause for multiple values
+	opBetween                    // BETWEEN range comparison
+	opIsNull                     // IS NULL check
+	opLikeEscape                 // LIKE pattern matching of escaped text
+	opIEqual                     // Case-insensitive equal comparison
+	opILike                      // Case-insensitive LIKE pattern matching of escaped text
+	opMatch                      // Full-text search
+	opCount                      // Number of operators, each of which has a NOT form
 )
 
 // operatorType defines the valid data types for each operator
 // Each element is a bitmask of compatible data types
-var operatorType [7]int = [7]int{
-	dBool & dDate & dFloat & dDouble & dInt & dLong & dText,    // Equal supports all types
-	dDate & dFloat & dDouble & dInt & dLong & dText,            // Greater than supports numeric and text types
-	dDate & dFloat & dDouble & dInt & dLong & dText,            // Less than supports numeric and text types
-	dText,                                                      // LIKE only supports text
-	dDate & dFloat & dDouble & dInt & dLong & dText,            // IN supports all types except bool
-	dDate & dFloat & dDouble & dInt & dLong,                    // BETWEEN supports numeric types
-	dBool & dDate & dFloat & dDouble & dInt & dLong & dText,    // IS NULL supports all types
+var operatorType [opCount]int = [opCount]int{
+	dBool & dDate & dFloat & dDouble & dInt & dLong & dText, // Equal supports all types
+	dDate & dFloat & dDouble & dInt & dLong & dText,         // Greater than supports numeric and text types
+	dDate & dFloat & dDouble & dInt & dLong & dText,         // Less than supports numeric and text types
+	dText, // LIKE only supports text
+	dDate & dFloat & dDouble & dInt & dLong & dText,         // IN supports all types except bool
+	dDate & dFloat & dDouble & dInt & dLong,                 // BETWEEN supports numeric types
+	dBool & dDate & dFloat & dDouble & dInt & dLong & dText, // IS NULL supports all types
+	dText, // Escaped LIKE only supports text
+	dText, // Case-insensitive equal only supports text
+	dText, // Case-insensitive LIKE only supports text
+	dText, // Full-text search only supports text
 }
-
@@ -0,0 +1,209 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+// Package where provides functionality for building SQL WHERE clauses
+package where
+
+import "fmt"
+
+// Query is a SELECT statement used as a subquery, such as one returned by mud.Subquery
+type Query interface {
+	// Select returns the SQL of the statement, rendering its conditions with
+	// the operator formats, quoting identifiers with quote and converting
+	// values to literals with value
+	//
+	// @param operators List of operator strings used to generate clauses
+	// @param quote Function that quotes an identifier
+	// @param value Function that converts a value to a SQL literal
+	// @return SQL string, or an empty string if the statement is invalid
+	Select(operators []string, quote func(string) string, value func(interface{}) (string, bool)) string
+}
+
+// existsClause tests whether a subquery returns any rows
+type existsClause struct {
+	conjunction conjunction
+	not         bool
+	query       Query
+}
+
+// render generates the SQL clause string representation
+//
+// @receiver e The clause instance
+// @param operators List of operator strings to use for generating the clause
+// @param value Function that converts a value to a SQL literal
+// @return SQL clause string or empty string if invalid
+func (e existsClause) render(operators []string, value func(interface{}) (string, bool)) string {
+	if e.query == nil {
+		return ""
+	}
+	q := e.query.Select(operators, identity(operators), literal(value))
+	if q == "" {
+		return ""
+	}
+	if e.not {
+		return fmt.Sprintf("NOT EXISTS (%s)", q)
+	}
+	return fmt.Sprintf("EXISTS (%s)", q)
+}
+
+// getConjunction returns the logical conjunction used to combine this clause
+// with other clauses in a WHERE condition
+//
+// @receiver e The clause instance
+// @return The conjunction used for combining clauses
+func (e *existsClause) getConjunction() conjunction {
+	return e.conjunction
+}
+
+// InQuery creates a new Builder with the first clause testing that the field
+// is one of the values returned by the subquery
+// @param field
+// @param q
+// @return *Builder
+func InQuery(field string, q Query) *Builder {
+	n := NewBuilder(conAnd)
+	n.children = append(n.children, newClause(conAnd, field, opIn, false, q))
+	return n
+}
+
+// NotInQuery creates a new Builder with the first clause testing that the
+// field is not one of the values returned by the subquery
+// @param field
+// @param q
+// @return *Builder
+func NotInQuery(field string, q Query) *Builder {
+	n := NewBuilder(conAnd)
+	n.children = append(n.children, newClause(conAnd, field, opIn, true, q))
+	return n
+}
+
+// Exists creates a new Builder with the first clause testing that the
+// subquery returns at least one row
+// @param q
+// @return *Builder
+func Exists(q Query) *Builder {
+	n := NewBuilder(conAnd)
+	n.children = append(n.children, &existsClause{conjunction: conAnd, query: q})
+	return n
+}
+
+// NotExists creates a new Builder with the first clause testing that the
+// subquery returns no rows
+// @param q
+// @return *Builder
+func NotExists(q Query) *Builder {
+	n := NewBuilder(conAnd)
+	n.children = append(n.children, &existsClause{conjunction: conAnd, not: true, query: q})
+	return n
+}
+
+// AndInQuery adds an in subquery clause to the clause with an AND conjunction
+// @receiver c
+// @param field
+// @param q
+// @return *Builder
+func (c *Builder) AndInQuery(field string, q Query) *Builder {
+	c.children = append(c.children, newClause(conAnd, field, opIn, false, q))
+	return c
+}
+
+// AndNotInQuery adds a not in subquery clause to the clause with an AND conjunction
+// @receiver c
+// @param field
+// @param q
+// @return *Builder
+func (c *Builder) AndNotInQuery(field string, q Query) *Builder {
+	c.children = append(c.children, newClause(conAnd, field, opIn, true, q))
+	return c
+}
+
+// AndExists adds an exists clause to the clause with an AND conjunction
+// @receiver c
+// @param q
+// @return *Builder
+func (c *Builder) AndExists(q Query) *Builder {
+	c.children = append(c.children, &existsClause{conjunction: conAnd, query: q})
+	return c
+}
+
+// AndNotExists adds a not exists clause to the clause with an AND conjunction
+// @receiver c
+// @param q
+// @return *Builder
+func (c *Builder) AndNotExists(q Query) *Builder {
+	c.children = append(c.children, &existsClause{conjunction: conAnd, not: true, query: q})
+	return c
+}
+
+// OrInQuery adds an in subquery clause to the clause with an OR conjunction
+func (c *Builder) OrInQuery(field string, q Query) *Builder {
+	c.children = append(c.children, newClause(conOr, field, opIn, false, q))
+	return c
+}
+
+// OrNotInQuery adds a not in subquery clause to the clause with an OR conjunction
+func (c *Builder) OrNotInQuery(field string, q Query) *Builder {
+	c.children = append(c.children, newClause(conOr, field, opIn, true, q))
+	return c
+}
+
+// OrExists adds an exists clause to the clause with an OR conjunction
+func (c *Builder) OrExists(q Query) *Builder {
+	c.children = append(c.children, &existsClause{conjunction: conOr, query: q})
+	return c
+}
+
+// OrNotExists adds a not exists clause to the clause with an OR conjunction
+func (c *Builder) OrNotExists(q Query) *Builder {
+	c.children = append(c.children, &existsClause{conjunction: conOr, not: true, query: q})
+	return c
+}
+
+// MapQueries returns a copy of the builder with each query it contains, as a
+// value of a clause, a raw argument or an EXISTS test, replaced by the result
+// of f. The builder itself is left unchanged
+//
+// @param f Function that returns the replacement of a query
+// @return *Builder
+func (c *Builder) MapQueries(f func(Query) Query) *Builder {
+	n := NewBuilder(c.conjunction)
+	for _, child := range c.children {
+		switch ch := child.(type) {
+		case *Builder:
+			n.children = append(n.children, ch.MapQueries(f))
+		case *clause:
+			cl := *ch
+			cl.values = mapValues(ch.values, f)
+			n.children = append(n.children, &cl)
+		case *rawClause:
+			r := *ch
+			r.args = mapValues(ch.args, f)
+			n.children = append(n.children, &r)
+		case *existsClause:
+			e := *ch
+			if e.query != nil {
+				e.query = f(e.query)
+			}
+			n.children = append(n.children, &e)
+		default:
+			n.children = append(n.children, child)
+		}
+	}
+	return n
+}
+
+// mapValues returns a copy of the values with each query replaced by the result of f
+//
+// @param values The values to copy
+// @param f Function that returns the replacement of a query
+// @return []interface{}
+func mapValues(values []interface{}, f func(Query) Query) []interface{} {
+	res := make([]interface{}, len(values))
+	for i, v := range values {
+		if q, ok := v.(Query); ok {
+			v = f(q)
+		}
+		res[i] = v
+	}
+	return res
+}
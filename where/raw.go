@@ -0,0 +1,106 @@
+// Copyright (c) 2025 DaggerTech. All rights reserved.
+// Use of this source code is governed by an MIT license that can be
+// found in the LICENSE file.
+// Package where provides functionality for building SQL WHERE clauses
+package where
+
+import "strings"
+
+// rawClause is a SQL condition given as an expression, whose ? placeholders
+// are replaced by the arguments
+type rawClause struct {
+	conjunction conjunction
+	expr        string
+	args        []interface{}
+}
+
+// render generates the SQL of the expression, with each placeholder replaced
+// by its argument. Placeholders within quoted strings are left as they are.
+// Slice arguments are expanded to comma separated lists, for use with IN
+//
+// @receiver r The clause instance
+// @param operators List of operator strings to use for generating the clause
+// @param value Function that converts a value to a SQL literal
+// @return SQL clause string or empty string if the arguments do not match
+func (r rawClause) render(operators []string, value func(interface{}) (string, bool)) string {
+	var sb strings.Builder
+	arg := 0
+	quoted := false
+	for _, ch := range r.expr {
+		switch {
+		case ch == '\'':
+			quoted = !quoted
+		case ch == '?' && !quoted:
+			if arg >= len(r.args) {
+				return ""
+			}
+			values := []interface{}{r.args[arg]}
+			if _, ok := r.args[arg].(string); !ok {
+				if vs := convertToInterfaceArray(r.args[arg]); len(vs) > 0 {
+					values = vs
+				}
+			}
+			vls := make([]string, 0, len(values))
+			for _, v := range values {
+				vl, ok := makeValue(v, operators, value)
+				if !ok {
+					return ""
+				}
+				vls = append(vls, vl)
+			}
+			sb.WriteString(strings.Join(vls, ","))
+			arg++
+			continue
+		}
+		sb.WriteRune(ch)
+	}
+	if arg != len(r.args) || sb.Len() == 0 {
+		return ""
+	}
+	return "(" + sb.String() + ")"
+}
+
+// getConjunction returns the logical conjunction used to combine this clause
+// with other clauses in a WHERE condition
+//
+// @receiver r The clause instance
+// @return The conjunction used for combining clauses
+func (r *rawClause) getConjunction() conjunction {
+	return r.conjunction
+}
+
+// Raw creates a new Builder with the first clause being a SQL expression, such as
+//
+//	where.Raw("LOWER(?) = ?", where.Col("Email"), "alex@example.com")
+//
+// Each ? in the expression is replaced by the next argument, which is
+// converted to a literal, or quoted as an identifier if it is a Column.
+// The rest of the expression is used as given
+// @param expr
+// @param args
+// @return *Builder
+func Raw(expr string, args ...interface{}) *Builder {
+	n := NewBuilder(conAnd)
+	n.children = append(n.children, &rawClause{conjunction: conAnd, expr: expr, args: args})
+	return n
+}
+
+// AndRaw adds a SQL expression to the clause with an AND conjunction
+// @receiver c
+// @param expr
+// @param args
+// @return *Builder
+func (c *Builder) AndRaw(expr string, args ...interface{}) *Builder {
+	c.children = append(c.children, &rawClause{conjunction: conAnd, expr: expr, args: args})
+	return c
+}
+
+// OrRaw adds a SQL expression to the clause with an OR conjunction
+// @receiver c
+// @param expr
+// @param args
+// @return *Builder
+func (c *Builder) OrRaw(expr string, args ...interface{}) *Builder {
+	c.children = append(c.children, &rawClause{conjunction: conOr, expr: expr, args: args})
+	return c
+}
@@ -33,10 +33,30 @@ func (c *Builder) Count() int {
 	return len(c.children)
 }
 
-// String returns the string version of the clause
+// String returns the string version of the clause, with values converted to
+// generic SQL literals
 // @receiver c
 // @return string
 func (c *Builder) String(operators []string) string {
+	return c.render(operators, nil)
+}
+
+// Render returns the string version of the clause, with values converted to
+// literals by value, so that they are escaped for the database dialect
+// @receiver c
+// @param operators
+// @param value
+// @return string
+func (c *Builder) Render(operators []string, value func(interface{}) (string, bool)) string {
+	return c.render(operators, value)
+}
+
+// render returns the string version of the clause
+// @receiver c
+// @param operators
+// @param value
+// @return string
+func (c *Builder) render(operators []string, value func(interface{}) (string, bool)) string {
 
 	result := ""
 	for _, child := range c.children {
@@ -44,14 +64,14 @@ func (c *Builder) String(operators []string) string {
 			result += string(child.getConjunction())
 		}
 
-		value := child.String(operators)
+		s := child.render(operators, value)
 		if _, ok := child.(*Builder); ok {
-			value = fmt.Sprintf("(%s)", value)
+			s = fmt.Sprintf("(%s)", s)
 		}
-		if value == "" {
+		if s == "" {
 			return ""
 		}
-		result += value
+		result += s
 	}
 	return result
 }
@@ -118,7 +138,7 @@ func Like(field string, value string) *Builder {
 // @return *Builder
 func StartsWith(field string, value string) *Builder {
 	n := NewBuilder(conAnd)
-	n.children = append(n.children, newClause(conAnd, field, opLike, false, value+"%"))
+	n.children = append(n.children, newPattern(conAnd, field, opLikeEscape, false, value, false, true))
 	return n
 }
 
@@ -128,7 +148,7 @@ func StartsWith(field string, value string) *Builder {
 // @return *Builder
 func EndsWith(field string, value string) *Builder {
 	n := NewBuilder(conAnd)
-	n.children = append(n.children, newClause(conAnd, field, opLike, false, "%"+value))
+	n.children = append(n.children, newPattern(conAnd, field, opLikeEscape, false, value, true, false))
 	return n
 }
 
@@ -138,7 +158,7 @@ func EndsWith(field string, value string) *Builder {
 // @return *Builder
 func Contains(field string, value string) *Builder {
 	n := NewBuilder(conAnd)
-	n.children = append(n.children, newClause(conAnd, field, opLike, false, "%"+value+"%"))
+	n.children = append(n.children, newPattern(conAnd, field, opLikeEscape, false, value, true, true))
 	return n
 }
 
@@ -228,7 +248,7 @@ func NotLike(field string, value string) *Builder {
 // @return *Builder
 func NotStartsWith(field string, value string) *Builder {
 	n := NewBuilder(conAnd)
-	n.children = append(n.children, newClause(conAnd, field, opLike, true, value+"%"))
+	n.children = append(n.children, newPattern(conAnd, field, opLikeEscape, true, value, false, true))
 	return n
 }
 
@@ -238,7 +258,7 @@ func NotStartsWith(field string, value string) *Builder {
 // @return *Builder
 func NotEndsWith(field string, value string) *Builder {
 	n := NewBuilder(conAnd)
-	n.children = append(n.children, newClause(conAnd, field, opLike, true, "%"+value))
+	n.children = append(n.children, newPattern(conAnd, field, opLikeEscape, true, value, true, false))
 	return n
 }
 
@@ -248,7 +268,7 @@ func NotEndsWith(field string, value string) *Builder {
 // @return *Builder
 func NotContains(field string, value string) *Builder {
 	n := NewBuilder(conAnd)
-	n.children = append(n.children, newClause(conAnd, field, opLike, true, "%"+value+"%"))
+	n.children = append(n.children, newPattern(conAnd, field, opLikeEscape, true, value, true, true))
 	return n
 }
 
@@ -318,19 +338,19 @@ func (c *Builder) AndLike(field string, value string) *Builder {
 
 // AndStartsWith add a starts with clause to the clause with an AND conjunction
 func (c *Builder) AndStartsWith(field string, value string) *Builder {
-	c.children = append(c.children, newClause(conAnd, field, opLike, false, value+"%"))
+	c.children = append(c.children, newPattern(conAnd, field, opLikeEscape, false, value, false, true))
 	return c
 }
 
 // AndEndsWith add a ends with clause to the clause with an AND conjunction
 func (c *Builder) AndEndsWith(field string, value string) *Builder {
-	c.children = append(c.children, newClause(conAnd, field, opLike, false, "%"+value))
+	c.children = append(c.children, newPattern(conAnd, field, opLikeEscape, false, value, true, false))
 	return c
 }
 
 // AndContains add a contains clause to the clause with an AND conjunction
 func (c *Builder) AndContains(field string, value string) *Builder {
-	c.children = append(c.children, newClause(conAnd, field, opLike, false, "%"+value+"%"))
+	c.children = append(c.children, newPattern(conAnd, field, opLikeEscape, false, value, true, true))
 	return c
 }
 
@@ -385,19 +405,19 @@ func (c *Builder) AndNotLike(field string, value string) *Builder {
 
 // AndNotStartsWith add a starts with clause to the clause with an AND conjunction
 func (c *Builder) AndNotStartsWith(field string, value string) *Builder {
-	c.children = append(c.children, newClause(conAnd, field, opLike, true, value+"%"))
+	c.children = append(c.children, newPattern(conAnd, field, opLikeEscape, true, value, false, true))
 	return c
 }
 
 // AndNotEndsWith add a ends with clause to the clause with an AND conjunction
 func (c *Builder) AndNotEndsWith(field string, value string) *Builder {
-	c.children = append(c.children, newClause(conAnd, field, opLike, true, "%"+value))
+	c.children = append(c.children, newPattern(conAnd, field, opLikeEscape, true, value, true, false))
 	return c
 }
 
 // AndNotContains add a contains clause to the clause with an AND conjunction
 func (c *Builder) AndNotContains(field string, value string) *Builder {
-	c.children = append(c.children, newClause(conAnd, field, opLike, true, "%"+value+"%"))
+	c.children = append(c.children, newPattern(conAnd, field, opLikeEscape, true, value, true, true))
 	return c
 }
 
@@ -447,19 +467,19 @@ func (c *Builder) OrLike(field string, value string) *Builder {
 
 // OrStartsWith add a starts with clause to the clause with an OR conjunction
 func (c *Builder) OrStartsWith(field string, value string) *Builder {
-	c.children = append(c.children, newClause(conOr, field, opLike, false, value+"%"))
+	c.children = append(c.children, newPattern(conOr, field, opLikeEscape, false, value, false, true))
 	return c
 }
 
 // OrEndsWith add a ends with clause to the clause with an OR conjunction
 func (c *Builder) OrEndsWith(field string, value string) *Builder {
-	c.children = append(c.children, newClause(conOr, field, opLike, false, "%"+value))
+	c.children = append(c.children, newPattern(conOr, field, opLikeEscape, false, value, true, false))
 	return c
 }
 
 // OrContains add a contains clause to the clause with an OR conjunction
 func (c *Builder) OrContains(field string, value string) *Builder {
-	c.children = append(c.children, newClause(conOr, field, opLike, false, "%"+value+"%"))
+	c.children = append(c.children, newPattern(conOr, field, opLikeEscape, false, value, true, true))
 	return c
 }
 
@@ -514,19 +534,19 @@ func (c *Builder) OrNotLike(field string, value string) *Builder {
 
 // OrNotStartsWith add a not starts with clause to the clause with an OR conjunction
 func (c *Builder) OrNotStartsWith(field string, value string) *Builder {
-	c.children = append(c.children, newClause(conOr, field, opLike, true, value+"%"))
+	c.children = append(c.children, newPattern(conOr, field, opLikeEscape, true, value, false, true))
 	return c
 }
 
 // OrNotEndsWith add a not ends with clause to the clause with an OR conjunction
 func (c *Builder) OrNotEndsWith(field string, value string) *Builder {
-	c.children = append(c.children, newClause(conOr, field, opLike, true, "%"+value))
+	c.children = append(c.children, newPattern(conOr, field, opLikeEscape, true, value, true, false))
 	return c
 }
 
 // OrNotContains add a not contains clause to the clause with an OR conjunction
 func (c *Builder) OrNotContains(field string, value string) *Builder {
-	c.children = append(c.children, newClause(conOr, field, opLike, true, "%"+string(value)+"%"))
+	c.children = append(c.children, newPattern(conOr, field, opLikeEscape, true, value, true, true))
 	return c
 }
 